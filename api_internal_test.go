@@ -5,7 +5,7 @@ import (
 	"testing"
 )
 
-func TestzhipuaiFullURL(t *testing.T) {
+func TestZhipuaiFullURL(t *testing.T) {
 	cases := []struct {
 		Name   string
 		Suffix string
@@ -14,12 +14,12 @@ func TestzhipuaiFullURL(t *testing.T) {
 		{
 			"ChatCompletionsURL",
 			"/chat/completions",
-			"https://api.zhipuai.com/v1/chat/completions",
+			"https://open.bigmodel.cn/api/paas/v4/chat/completions",
 		},
 		{
 			"CompletionsURL",
 			"/completions",
-			"https://api.zhipuai.com/v1/completions",
+			"https://open.bigmodel.cn/api/paas/v4/completions",
 		},
 	}
 
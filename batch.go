@@ -0,0 +1,413 @@
+package zhipuai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const batchesSuffix = "/batches"
+
+// defaultBatchCompletionWindow is the only completion window accepted by the API.
+const defaultBatchCompletionWindow = "24h"
+
+// maxBatchResultLineSize bounds a single line of a batch output file.
+const maxBatchResultLineSize = 16 << 20
+
+var (
+	ErrBatchMissingCustomID   = errors.New("batch request requires a custom_id")
+	ErrBatchDuplicateCustomID = errors.New("custom_id is already used in this batch")
+	ErrBatchEndpointMismatch  = errors.New("request type does not match the endpoint of this batch")
+	ErrBatchEmpty             = errors.New("batch has no requests")
+	ErrBatchResultNotFound    = errors.New("no result for this custom_id")
+)
+
+// BatchEndpoint is the API path the requests of a batch are sent to.
+type BatchEndpoint string
+
+const (
+	BatchEndpointChatCompletions BatchEndpoint = "/v4/chat/completions"
+	BatchEndpointEmbeddings      BatchEndpoint = "/v4/embeddings"
+)
+
+// BatchStatus is the processing state of a batch.
+type BatchStatus string
+
+const (
+	BatchStatusValidating BatchStatus = "validating"
+	BatchStatusFailed     BatchStatus = "failed"
+	BatchStatusInProgress BatchStatus = "in_progress"
+	BatchStatusFinalizing BatchStatus = "finalizing"
+	BatchStatusCompleted  BatchStatus = "completed"
+	BatchStatusExpired    BatchStatus = "expired"
+	BatchStatusCancelling BatchStatus = "cancelling"
+	BatchStatusCancelled  BatchStatus = "cancelled"
+)
+
+// Done reports whether a batch in this status will not change anymore.
+func (s BatchStatus) Done() bool {
+	switch s {
+	case BatchStatusCompleted, BatchStatusFailed, BatchStatusExpired, BatchStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+type BatchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+	Line    *int   `json:"line,omitempty"`
+}
+
+type BatchErrors struct {
+	Object string       `json:"object"`
+	Data   []BatchError `json:"data"`
+}
+
+// Batch is a group of requests processed asynchronously.
+type Batch struct {
+	ID               string             `json:"id"`
+	Object           string             `json:"object"`
+	Endpoint         BatchEndpoint      `json:"endpoint"`
+	Errors           *BatchErrors       `json:"errors,omitempty"`
+	InputFileID      string             `json:"input_file_id"`
+	CompletionWindow string             `json:"completion_window"`
+	Status           BatchStatus        `json:"status"`
+	OutputFileID     string             `json:"output_file_id,omitempty"`
+	ErrorFileID      string             `json:"error_file_id,omitempty"`
+	CreatedAt        int64              `json:"created_at"`
+	InProgressAt     int64              `json:"in_progress_at,omitempty"`
+	ExpiresAt        int64              `json:"expires_at,omitempty"`
+	FinalizingAt     int64              `json:"finalizing_at,omitempty"`
+	CompletedAt      int64              `json:"completed_at,omitempty"`
+	FailedAt         int64              `json:"failed_at,omitempty"`
+	ExpiredAt        int64              `json:"expired_at,omitempty"`
+	CancellingAt     int64              `json:"cancelling_at,omitempty"`
+	CancelledAt      int64              `json:"cancelled_at,omitempty"`
+	RequestCounts    BatchRequestCounts `json:"request_counts"`
+	Metadata         map[string]string  `json:"metadata,omitempty"`
+
+	httpHeader
+}
+
+// BatchRequest represents a request to create a batch.
+type BatchRequest struct {
+	InputFileID string        `json:"input_file_id"`
+	Endpoint    BatchEndpoint `json:"endpoint"`
+	// CompletionWindow defaults to "24h".
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	// AutoDeleteInputFile deletes the input file once the batch is done.
+	AutoDeleteInputFile bool `json:"auto_delete_input_file,omitempty"`
+}
+
+// BatchList is a page of batches.
+type BatchList struct {
+	Object  string  `json:"object"`
+	Batches []Batch `json:"data"`
+	FirstID string  `json:"first_id"`
+	LastID  string  `json:"last_id"`
+	HasMore bool    `json:"has_more"`
+
+	httpHeader
+}
+
+// CreateBatch creates a batch from an uploaded input file.
+func (c *Client) CreateBatch(ctx context.Context, request BatchRequest) (response Batch, err error) {
+	if request.CompletionWindow == "" {
+		request.CompletionWindow = defaultBatchCompletionWindow
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(batchesSuffix), withBody(request))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// RetrieveBatch retrieves a batch.
+func (c *Client) RetrieveBatch(ctx context.Context, batchID string) (response Batch, err error) {
+	urlSuffix := fmt.Sprintf("%s/%s", batchesSuffix, batchID)
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// CancelBatch cancels a batch that is still in progress.
+func (c *Client) CancelBatch(ctx context.Context, batchID string) (response Batch, err error) {
+	urlSuffix := fmt.Sprintf("%s/%s/cancel", batchesSuffix, batchID)
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// ListBatches lists the batches of the account, most recent first.
+func (c *Client) ListBatches(ctx context.Context, after *string, limit *int) (response BatchList, err error) {
+	urlValues := url.Values{}
+	if after != nil {
+		urlValues.Add("after", *after)
+	}
+	if limit != nil {
+		urlValues.Add("limit", strconv.Itoa(*limit))
+	}
+
+	encodedValues := ""
+	if len(urlValues) > 0 {
+		encodedValues = "?" + urlValues.Encode()
+	}
+
+	urlSuffix := batchesSuffix + encodedValues
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// WaitBatch polls a batch until it is completed, failed, expired or cancelled,
+// or ctx is done. Batches that did not complete are returned without an error,
+// since they may still hold partial results.
+func (c *Client) WaitBatch(ctx context.Context, batchID string, opts AsyncPollOptions) (response Batch, err error) {
+	err = poll(ctx, opts, func() (bool, error) {
+		response, err = c.RetrieveBatch(ctx, batchID)
+		if err != nil {
+			return false, err
+		}
+		return response.Status.Done(), nil
+	})
+	return
+}
+
+type batchRequestLine struct {
+	CustomID string        `json:"custom_id"`
+	Method   string        `json:"method"`
+	URL      BatchEndpoint `json:"url"`
+	Body     any           `json:"body"`
+}
+
+// BatchRequestWriter builds the JSONL input file of a batch.
+type BatchRequestWriter struct {
+	endpoint  BatchEndpoint
+	buf       bytes.Buffer
+	customIDs map[string]bool
+}
+
+// NewBatchRequestWriter creates a writer for requests sent to endpoint.
+func NewBatchRequestWriter(endpoint BatchEndpoint) *BatchRequestWriter {
+	return &BatchRequestWriter{
+		endpoint:  endpoint,
+		customIDs: make(map[string]bool),
+	}
+}
+
+// Endpoint returns the endpoint the requests of the batch are sent to.
+func (w *BatchRequestWriter) Endpoint() BatchEndpoint {
+	return w.endpoint
+}
+
+// AddChatCompletion adds a chat completion request identified by customID.
+func (w *BatchRequestWriter) AddChatCompletion(customID string, request ChatCompletionRequest) error {
+	if w.endpoint != BatchEndpointChatCompletions {
+		return ErrBatchEndpointMismatch
+	}
+	if request.Stream {
+		return ErrChatCompletionStreamNotSupported
+	}
+	if !checkEndpointSupportsModel(chatCompletionsSuffix, request.Model) {
+		return ErrChatCompletionInvalidModel
+	}
+	if err := validateChatCompletionRequest(request); err != nil {
+		return err
+	}
+	return w.add(customID, request)
+}
+
+// AddEmbedding adds an embedding request identified by customID.
+func (w *BatchRequestWriter) AddEmbedding(customID string, request EmbeddingRequestConverter) error {
+	if w.endpoint != BatchEndpointEmbeddings {
+		return ErrBatchEndpointMismatch
+	}
+	baseReq := request.Convert()
+	if !checkEndpointSupportsModel(embeddingsSuffix, string(baseReq.Model)) {
+		return ErrEmbeddingInvalidModel
+	}
+	return w.add(customID, baseReq)
+}
+
+func (w *BatchRequestWriter) add(customID string, body any) error {
+	if customID == "" {
+		return ErrBatchMissingCustomID
+	}
+	if w.customIDs[customID] {
+		return fmt.Errorf("%w: %s", ErrBatchDuplicateCustomID, customID)
+	}
+
+	line, err := json.Marshal(batchRequestLine{
+		CustomID: customID,
+		Method:   http.MethodPost,
+		URL:      w.endpoint,
+		Body:     body,
+	})
+	if err != nil {
+		return err
+	}
+	w.buf.Write(line)
+	w.buf.WriteByte('\n')
+	w.customIDs[customID] = true
+	return nil
+}
+
+// Len returns the number of requests written.
+func (w *BatchRequestWriter) Len() int {
+	return len(w.customIDs)
+}
+
+// Bytes returns the JSONL content of the batch input file.
+func (w *BatchRequestWriter) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+// UploadBatchRequests uploads the requests of a writer as a batch input file.
+func (c *Client) UploadBatchRequests(
+	ctx context.Context,
+	name string,
+	requests *BatchRequestWriter,
+) (file File, err error) {
+	if requests.Len() == 0 {
+		err = ErrBatchEmpty
+		return
+	}
+
+	return c.CreateFileBytes(ctx, FileBytesRequest{
+		Name:    name,
+		Bytes:   requests.Bytes(),
+		Purpose: PurposeBatch,
+	})
+}
+
+// BatchResponse is the response to a single request of a batch.
+type BatchResponse struct {
+	StatusCode int             `json:"status_code"`
+	RequestID  string          `json:"request_id"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// BatchResult is the outcome of a single request of a batch.
+type BatchResult struct {
+	ID       string         `json:"id"`
+	CustomID string         `json:"custom_id"`
+	Response *BatchResponse `json:"response"`
+	Error    *BatchError    `json:"error"`
+}
+
+// Decode unmarshals the response body into v, e.g. a *ChatCompletionResponse
+// or an *EmbeddingResponse. Failed requests are reported as an *APIError.
+func (r BatchResult) Decode(v any) error {
+	if r.Error != nil {
+		return &APIError{Code: r.Error.Code, Message: r.Error.Message}
+	}
+	if r.Response == nil {
+		return fmt.Errorf("%w: %s", ErrBatchResultNotFound, r.CustomID)
+	}
+	if isFailureStatusCode(&http.Response{StatusCode: r.Response.StatusCode}) {
+		var errRes ErrorResponse
+		if err := json.Unmarshal(r.Response.Body, &errRes); err != nil || errRes.Error == nil {
+			return &RequestError{HTTPStatusCode: r.Response.StatusCode, Err: err}
+		}
+		errRes.Error.HTTPStatusCode = r.Response.StatusCode
+		return errRes.Error
+	}
+	return json.Unmarshal(r.Response.Body, v)
+}
+
+// ChatCompletion decodes the response of a chat completion request.
+func (r BatchResult) ChatCompletion() (response ChatCompletionResponse, err error) {
+	err = r.Decode(&response)
+	return
+}
+
+// Embedding decodes the response of an embedding request.
+func (r BatchResult) Embedding() (response EmbeddingResponse, err error) {
+	err = r.Decode(&response)
+	return
+}
+
+// BatchResults maps the custom ids of a batch to the outcome of their request.
+type BatchResults map[string]BatchResult
+
+// ReadBatchResults parses a batch output or error file.
+func ReadBatchResults(r io.Reader) (BatchResults, error) {
+	results := make(BatchResults)
+	if err := results.read(r); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (results BatchResults) read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxBatchResultLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var result BatchResult
+		if err := json.Unmarshal(line, &result); err != nil {
+			return err
+		}
+		results[result.CustomID] = result
+	}
+	return scanner.Err()
+}
+
+// GetBatchResults downloads the output and error files of a batch and merges
+// them by custom id.
+func (c *Client) GetBatchResults(ctx context.Context, batch Batch) (results BatchResults, err error) {
+	results = make(BatchResults)
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		if err = c.readBatchFile(ctx, fileID, results); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+func (c *Client) readBatchFile(ctx context.Context, fileID string, results BatchResults) error {
+	content, err := c.GetFileContent(ctx, fileID)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	return results.read(content)
+}
@@ -0,0 +1,184 @@
+package zhipuai_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+func TestBatchRequestWriter(t *testing.T) {
+	writer := zhipuai.NewBatchRequestWriter(zhipuai.BatchEndpointChatCompletions)
+	checks.NoError(t, writer.AddChatCompletion("req-1", testChatCompletionRequest), "AddChatCompletion error")
+	checks.NoError(t, writer.AddChatCompletion("req-2", testChatCompletionRequest), "AddChatCompletion error")
+
+	err := writer.AddChatCompletion("req-1", testChatCompletionRequest)
+	checks.ErrorIs(t, err, zhipuai.ErrBatchDuplicateCustomID, "duplicate custom_id should be rejected")
+	err = writer.AddChatCompletion("", testChatCompletionRequest)
+	checks.ErrorIs(t, err, zhipuai.ErrBatchMissingCustomID, "empty custom_id should be rejected")
+	err = writer.AddEmbedding("req-3", zhipuai.EmbeddingRequestStrings{Input: []string{"hi"}})
+	checks.ErrorIs(t, err, zhipuai.ErrBatchEndpointMismatch, "embedding should not be added to a chat batch")
+
+	if writer.Len() != 2 {
+		t.Fatalf("expected 2 requests, got %d", writer.Len())
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(writer.Bytes()))
+	for i := 1; scanner.Scan(); i++ {
+		var line struct {
+			CustomID string                        `json:"custom_id"`
+			Method   string                        `json:"method"`
+			URL      string                        `json:"url"`
+			Body     zhipuai.ChatCompletionRequest `json:"body"`
+		}
+		checks.NoError(t, json.Unmarshal(scanner.Bytes(), &line), "line should be valid JSON")
+		if line.CustomID != fmt.Sprintf("req-%d", i) || line.Method != http.MethodPost ||
+			line.URL != "/v4/chat/completions" || line.Body.Model != testChatCompletionRequest.Model {
+			t.Errorf("unexpected line %d: %+v", i, line)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+
+	var uploaded []byte
+	server.RegisterHandler("/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1024 * 1024); err != nil || r.FormValue("purpose") != "batch" {
+			http.Error(w, "unexpected form", http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, "missing file", http.StatusBadRequest)
+			return
+		}
+		uploaded, _ = io.ReadAll(file)
+		fmt.Fprintln(w, `{"id":"file-in","purpose":"batch"}`)
+	})
+	server.RegisterHandler("/v1/batches", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if r.URL.Query().Get("limit") != "1" {
+				http.Error(w, "unexpected query", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintln(w, `{"object":"list","data":[{"id":"batch-1"}],"has_more":true}`)
+			return
+		}
+		var request zhipuai.BatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "could not read request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"id":"batch-1","status":"validating","input_file_id":%q,"endpoint":%q,"completion_window":%q}`,
+			request.InputFileID, request.Endpoint, request.CompletionWindow)
+	})
+	polls := 0
+	server.RegisterHandler("/v1/batches/batch-1", func(w http.ResponseWriter, _ *http.Request) {
+		polls++
+		if polls < 2 {
+			fmt.Fprintln(w, `{"id":"batch-1","status":"in_progress"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"id":"batch-1","status":"completed","output_file_id":"file-out","error_file_id":"file-err",`+
+			`"request_counts":{"total":2,"completed":1,"failed":1}}`)
+	})
+	server.RegisterHandler("/v1/batches/batch-1/cancel", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprintln(w, `{"id":"batch-1","status":"cancelling"}`)
+	})
+	server.RegisterHandler("/v1/files/file-out/content", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"id":"r1","custom_id":"req-1","response":{"status_code":200,"request_id":"x",`+
+			`"body":{"id":"c1","choices":[{"index":0,"message":{"role":"assistant","content":"hi"}}]}}}`)
+	})
+	server.RegisterHandler("/v1/files/file-err/content", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"id":"r2","custom_id":"req-2","response":{"status_code":400,`+
+			`"body":{"error":{"code":"1210","message":"invalid parameter"}}}}`)
+	})
+
+	ctx := context.Background()
+	writer := zhipuai.NewBatchRequestWriter(zhipuai.BatchEndpointChatCompletions)
+	_, err := client.UploadBatchRequests(ctx, "batch.jsonl", writer)
+	checks.ErrorIs(t, err, zhipuai.ErrBatchEmpty, "empty batch should not be uploaded")
+
+	checks.NoError(t, writer.AddChatCompletion("req-1", testChatCompletionRequest), "AddChatCompletion error")
+	checks.NoError(t, writer.AddChatCompletion("req-2", testChatCompletionRequest), "AddChatCompletion error")
+	file, err := client.UploadBatchRequests(ctx, "batch.jsonl", writer)
+	checks.NoError(t, err, "UploadBatchRequests error")
+	if !bytes.Equal(uploaded, writer.Bytes()) {
+		t.Errorf("uploaded content differs from the writer content")
+	}
+
+	batch, err := client.CreateBatch(ctx, zhipuai.BatchRequest{InputFileID: file.ID, Endpoint: writer.Endpoint()})
+	checks.NoError(t, err, "CreateBatch error")
+	if batch.InputFileID != "file-in" || batch.CompletionWindow != "24h" ||
+		batch.Endpoint != zhipuai.BatchEndpointChatCompletions {
+		t.Errorf("unexpected batch: %+v", batch)
+	}
+
+	limit := 1
+	list, err := client.ListBatches(ctx, nil, &limit)
+	checks.NoError(t, err, "ListBatches error")
+	if len(list.Batches) != 1 || !list.HasMore {
+		t.Errorf("unexpected batch list: %+v", list)
+	}
+
+	batch, err = client.CancelBatch(ctx, batch.ID)
+	checks.NoError(t, err, "CancelBatch error")
+	if batch.Status != zhipuai.BatchStatusCancelling {
+		t.Errorf("expected cancelling status, got %s", batch.Status)
+	}
+
+	batch, err = client.WaitBatch(ctx, "batch-1", testAsyncPollOptions)
+	checks.NoError(t, err, "WaitBatch error")
+	if batch.Status != zhipuai.BatchStatusCompleted || polls != 2 {
+		t.Fatalf("expected completed batch after 2 polls, got %s after %d", batch.Status, polls)
+	}
+
+	results, err := client.GetBatchResults(ctx, batch)
+	checks.NoError(t, err, "GetBatchResults error")
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	completion, err := results["req-1"].ChatCompletion()
+	checks.NoError(t, err, "ChatCompletion error")
+	if completion.Choices[0].Message.Content != "hi" {
+		t.Errorf("unexpected completion: %+v", completion)
+	}
+	_, err = results["req-2"].ChatCompletion()
+	var apiErr *zhipuai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusBadRequest {
+		t.Errorf("expected APIError with status 400, got %v", err)
+	}
+}
+
+func TestReadBatchResults(t *testing.T) {
+	input := `{"custom_id":"a","error":{"code":"expired","message":"batch expired"}}` + "\n\n" +
+		`{"custom_id":"b","response":{"status_code":200,"body":{"data":[{"embedding":[0.5]}]}}}` + "\n"
+	results, err := zhipuai.ReadBatchResults(strings.NewReader(input))
+	checks.NoError(t, err, "ReadBatchResults error")
+
+	_, err = results["a"].Embedding()
+	checks.HasError(t, err, "errored request should be reported")
+
+	embedding, err := results["b"].Embedding()
+	checks.NoError(t, err, "Embedding error")
+	if len(embedding.Data) != 1 || embedding.Data[0].Embedding[0] != 0.5 {
+		t.Errorf("unexpected embedding: %+v", embedding)
+	}
+
+	_, err = zhipuai.ReadBatchResults(strings.NewReader("not json\n"))
+	checks.HasError(t, err, "malformed line should be reported")
+}
@@ -0,0 +1,115 @@
+package zhipuai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrCharacterMetaRequired     = errors.New("character chat completions require the meta character settings")
+	ErrCharacterMetaMissingField = errors.New("character meta is missing a required field")
+	ErrCharacterChatInvalidModel = errors.New("this model does not support character meta")
+)
+
+// CharacterMeta holds the character settings of a role-play chat with a
+// CharacterGLM model. Every field is required.
+type CharacterMeta struct {
+	// UserInfo describes the user.
+	UserInfo string `json:"user_info"`
+	// BotInfo describes the character played by the model.
+	BotInfo string `json:"bot_info"`
+	// BotName is the name of the character played by the model.
+	BotName string `json:"bot_name"`
+	// UserName is the name of the user.
+	UserName string `json:"user_name"`
+}
+
+// Persona describes one side of a role-play chat.
+type Persona struct {
+	Name string
+	Info string
+}
+
+// NewCharacterMeta returns the settings of a role-play chat between the user and the bot.
+func NewCharacterMeta(user, bot Persona) CharacterMeta {
+	return CharacterMeta{
+		UserInfo: user.Info,
+		BotInfo:  bot.Info,
+		BotName:  bot.Name,
+		UserName: user.Name,
+	}
+}
+
+// User returns the persona of the user.
+func (m CharacterMeta) User() Persona {
+	return Persona{Name: m.UserName, Info: m.UserInfo}
+}
+
+// Bot returns the persona of the character played by the model.
+func (m CharacterMeta) Bot() Persona {
+	return Persona{Name: m.BotName, Info: m.BotInfo}
+}
+
+// Validate checks that every field of the settings is set.
+func (m CharacterMeta) Validate() error {
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{"user_info", m.UserInfo},
+		{"bot_info", m.BotInfo},
+		{"bot_name", m.BotName},
+		{"user_name", m.UserName},
+	} {
+		if field.value == "" {
+			return fmt.Errorf("%w: %s", ErrCharacterMetaMissingField, field.name)
+		}
+	}
+	return nil
+}
+
+// CreateCharacterChatCompletion is an API call to chat with a CharacterGLM model
+// playing the character described by request.Meta. The model defaults to CharGLM3.
+func (c *Client) CreateCharacterChatCompletion(
+	ctx context.Context,
+	request ChatCompletionRequest,
+) (response ChatCompletionResponse, err error) {
+	if request, err = prepareCharacterRequest(request); err != nil {
+		return
+	}
+	return c.CreateChatCompletion(ctx, request)
+}
+
+// CreateCharacterChatCompletionStream is the streaming version of CreateCharacterChatCompletion.
+func (c *Client) CreateCharacterChatCompletionStream(
+	ctx context.Context,
+	request ChatCompletionRequest,
+) (stream *ChatCompletionStream, err error) {
+	if request, err = prepareCharacterRequest(request); err != nil {
+		return
+	}
+	return c.CreateChatCompletionStream(ctx, request)
+}
+
+func prepareCharacterRequest(request ChatCompletionRequest) (ChatCompletionRequest, error) {
+	if request.Meta == nil {
+		return request, ErrCharacterMetaRequired
+	}
+	if request.Model == "" {
+		request.Model = CharGLM3
+	}
+	return request, nil
+}
+
+// validateCharacterMeta checks the character settings of a request, which are
+// only accepted by CharacterGLM models.
+func validateCharacterMeta(request ChatCompletionRequest) error {
+	if request.Meta == nil {
+		return nil
+	}
+	if capabilities, ok := LookupModel(request.Model); ok && !capabilities.SupportsCharacterMeta {
+		return ErrCharacterChatInvalidModel
+	}
+	return request.Meta.Validate()
+}
@@ -0,0 +1,94 @@
+package zhipuai_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+var testCharacterMeta = zhipuai.NewCharacterMeta(
+	zhipuai.Persona{Name: "Lin", Info: "A student who loves astronomy."},
+	zhipuai.Persona{Name: "Nova", Info: "A cheerful guide to the night sky."},
+)
+
+func TestCharacterMeta(t *testing.T) {
+	if testCharacterMeta.BotName != "Nova" || testCharacterMeta.UserInfo != "A student who loves astronomy." {
+		t.Errorf("unexpected meta: %+v", testCharacterMeta)
+	}
+	if testCharacterMeta.User().Name != "Lin" || testCharacterMeta.Bot().Info != "A cheerful guide to the night sky." {
+		t.Errorf("unexpected personas: %+v %+v", testCharacterMeta.User(), testCharacterMeta.Bot())
+	}
+
+	meta := testCharacterMeta
+	meta.BotInfo = ""
+	err := meta.Validate()
+	checks.ErrorIs(t, err, zhipuai.ErrCharacterMetaMissingField, "bot_info is required")
+	if err.Error() != "character meta is missing a required field: bot_info" {
+		t.Errorf("expected the field name in the error, got %q", err)
+	}
+}
+
+func TestCreateCharacterChatCompletion(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		req, err := getChatCompletionBody(r)
+		checks.NoError(t, err, "could not read request")
+		if req.Model != zhipuai.CharGLM3 || req.Meta == nil || *req.Meta != testCharacterMeta {
+			http.Error(w, "unexpected character request", http.StatusBadRequest)
+			return
+		}
+		if req.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, `data: {"id":"1","choices":[{"index":0,"delta":{"content":"Look up!"}}]}`+"\n\ndata: [DONE]\n\n")
+			return
+		}
+		resBytes, _ := json.Marshal(zhipuai.ChatCompletionResponse{Choices: []zhipuai.ChatCompletionChoice{{
+			Message: zhipuai.ChatCompletionMessage{Role: zhipuai.ChatMessageRoleAssistant, Content: "Look up!"},
+		}}})
+		fmt.Fprintln(w, string(resBytes))
+	})
+
+	req := zhipuai.ChatCompletionRequest{
+		Messages: []zhipuai.ChatCompletionMessage{{Role: zhipuai.ChatMessageRoleUser, Content: "Where is Vega?"}},
+		Meta:     &testCharacterMeta,
+	}
+	resp, err := client.CreateCharacterChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "CreateCharacterChatCompletion error")
+	if resp.Choices[0].Message.Content != "Look up!" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	stream, err := client.CreateCharacterChatCompletionStream(context.Background(), req)
+	checks.NoError(t, err, "CreateCharacterChatCompletionStream error")
+	defer stream.Close()
+	resp, err = stream.Collect()
+	checks.NoError(t, err, "stream error")
+	if resp.Choices[0].Message.Content != "Look up!" {
+		t.Errorf("unexpected streamed response: %+v", resp)
+	}
+}
+
+func TestCreateCharacterChatCompletionValidation(t *testing.T) {
+	client := newOfflineClient()
+	ctx := context.Background()
+
+	req := testChatCompletionRequest
+	req.Model = ""
+	_, err := client.CreateCharacterChatCompletion(ctx, req)
+	checks.ErrorIs(t, err, zhipuai.ErrCharacterMetaRequired, "meta is required")
+
+	req.Meta = &zhipuai.CharacterMeta{BotName: "Nova"}
+	_, err = client.CreateCharacterChatCompletionStream(ctx, req)
+	checks.ErrorIs(t, err, zhipuai.ErrCharacterMetaMissingField, "incomplete meta should be rejected")
+
+	req.Model = zhipuai.GLM4
+	req.Meta = &testCharacterMeta
+	_, err = client.CreateChatCompletion(ctx, req)
+	checks.ErrorIs(t, err, zhipuai.ErrCharacterChatInvalidModel, "meta is only accepted by CharacterGLM models")
+}
@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+
+	"github.com/bbang94/go-zhipuai/jsonschema"
 )
 
 // Chat message role defined by the zhipuai API.
@@ -19,9 +23,12 @@ const (
 const chatCompletionsSuffix = "/chat/completions"
 
 var (
+	ErrRetrievalMissingKnowledgeID      = errors.New("retrieval tool requires a knowledge_id")
+	ErrRetrievalTemplatePlaceholder     = errors.New("retrieval prompt_template must contain {{knowledge}} and {{question}}")                           //nolint:lll
 	ErrChatCompletionInvalidModel       = errors.New("this model is not supported with this method, please use CreateCompletion client method instead") //nolint:lll
 	ErrChatCompletionStreamNotSupported = errors.New("streaming is not supported with this method, please use CreateChatCompletionStream")              //nolint:lll
 	ErrContentFieldsMisused             = errors.New("can't use both Content and MultiContent properties simultaneously")
+	ErrChatMessagePartInvalid           = errors.New("the chat message part has no payload for its type")
 )
 
 type Hate struct {
@@ -66,17 +73,24 @@ type ChatMessageImageURL struct {
 	Detail ImageURLDetail `json:"detail,omitempty"`
 }
 
+// ChatMessageVideoURL is the video of a video_url part, only accepted by video-capable models.
+type ChatMessageVideoURL struct {
+	URL string `json:"url,omitempty"`
+}
+
 type ChatMessagePartType string
 
 const (
 	ChatMessagePartTypeText     ChatMessagePartType = "text"
 	ChatMessagePartTypeImageURL ChatMessagePartType = "image_url"
+	ChatMessagePartTypeVideoURL ChatMessagePartType = "video_url"
 )
 
 type ChatMessagePart struct {
 	Type     ChatMessagePartType  `json:"type,omitempty"`
 	Text     string               `json:"text,omitempty"`
 	ImageURL *ChatMessageImageURL `json:"image_url,omitempty"`
+	VideoURL *ChatMessageVideoURL `json:"video_url,omitempty"`
 }
 
 type ChatCompletionMessage struct {
@@ -103,6 +117,11 @@ func (m ChatCompletionMessage) MarshalJSON() ([]byte, error) {
 	if m.Content != "" && m.MultiContent != nil {
 		return nil, ErrContentFieldsMisused
 	}
+	for i, part := range m.MultiContent {
+		if err := part.validate(); err != nil {
+			return nil, fmt.Errorf("content[%d]: %w", i, err)
+		}
+	}
 	if len(m.MultiContent) > 0 {
 		msg := struct {
 			Role         string            `json:"role"`
@@ -215,26 +234,95 @@ type ChatCompletionRequest struct {
 	FunctionCall any    `json:"function_call,omitempty"`
 	Tools        []Tool `json:"tools,omitempty"`
 	// This can be either a string or an ToolChoice object.
+	// GLM models only accept ToolChoiceAuto.
 	ToolChoice any `json:"tool_choice,omitempty"`
+	// RequestID is a unique id of the request chosen by the caller. The server generates one when empty.
+	RequestID string `json:"request_id,omitempty"`
+	// DoSample enables sampling with Temperature and TopP, which are ignored when it is false.
+	// Defaults to true.
+	DoSample *bool `json:"do_sample,omitempty"`
+	// UserID identifies the end user, to help the platform detect abuse. It is 6 to 128 characters long.
+	UserID string `json:"user_id,omitempty"`
+	// Meta holds the character settings of a role-play chat, only accepted by CharacterGLM models.
+	Meta *CharacterMeta `json:"meta,omitempty"`
 }
 
 type ToolType string
 
 const (
-	ToolTypeFunction ToolType = "function"
+	ToolTypeFunction  ToolType = "function"
+	ToolTypeRetrieval ToolType = "retrieval"
+	ToolTypeWebSearch ToolType = "web_search"
 )
 
+// Placeholders a custom retrieval prompt template must contain.
+const (
+	RetrievalKnowledgePlaceholder = "{{knowledge}}"
+	RetrievalQuestionPlaceholder  = "{{question}}"
+)
+
+// RetrievalDefinition configures the retrieval tool, which answers from a knowledge base.
 type RetrievalDefinition struct {
-	KnowledgeId    string `json:"knowledge_id"`
-	PromptTemplate string `json:"prompt_template"`
+	KnowledgeId string `json:"knowledge_id"` //nolint:revive // backwards-compatibility
+	// PromptTemplate optionally replaces the default prompt. It must contain the
+	// {{knowledge}} and {{question}} placeholders.
+	PromptTemplate string `json:"prompt_template,omitempty"`
+}
+
+// Validate checks that the knowledge base is set and that a custom prompt
+// template contains the required placeholders.
+func (r RetrievalDefinition) Validate() error {
+	if r.KnowledgeId == "" {
+		return ErrRetrievalMissingKnowledgeID
+	}
+	if r.PromptTemplate != "" && (!strings.Contains(r.PromptTemplate, RetrievalKnowledgePlaceholder) ||
+		!strings.Contains(r.PromptTemplate, RetrievalQuestionPlaceholder)) {
+		return ErrRetrievalTemplatePlaceholder
+	}
+	return nil
+}
+
+// RetrievalReference is a knowledge base chunk the model used to answer.
+type RetrievalReference struct {
+	KnowledgeID  string  `json:"knowledge_id,omitempty"`
+	DocumentID   string  `json:"document_id,omitempty"`
+	DocumentName string  `json:"document_name,omitempty"`
+	Content      string  `json:"content,omitempty"`
+	Score        float64 `json:"score,omitempty"`
+}
+
+// WebSearchDefinition configures the web_search tool.
+type WebSearchDefinition struct {
+	// Enable turns the search on. The API enables it by default, but as the zero
+	// value it must be set explicitly here.
+	Enable bool `json:"enable"`
+	// SearchQuery overrides the query derived from the conversation.
+	SearchQuery string `json:"search_query,omitempty"`
+	// SearchResult requests the search results in ChatCompletionResponse.WebSearch.
+	SearchResult bool `json:"search_result,omitempty"`
+}
+
+// WebSearchResult is a web page the model referred to when answering.
+type WebSearchResult struct {
+	Icon    string `json:"icon,omitempty"`
+	Title   string `json:"title,omitempty"`
+	Link    string `json:"link,omitempty"`
+	Media   string `json:"media,omitempty"`
+	Content string `json:"content,omitempty"`
+	// Refer is the citation marker used in the answer, e.g. "[ref_1]".
+	Refer string `json:"refer,omitempty"`
 }
 
 type Tool struct {
 	Type      ToolType             `json:"type"`
 	Function  *FunctionDefinition  `json:"function,omitempty"`
 	Retrieval *RetrievalDefinition `json:"retrieval,omitempty"`
+	WebSearch *WebSearchDefinition `json:"web_search,omitempty"`
 }
 
+// ToolChoiceAuto lets the model decide whether to call a tool.
+const ToolChoiceAuto = "auto"
+
 type ToolChoice struct {
 	Type     ToolType     `json:"type"`
 	Function ToolFunction `json:"function,omitempty"`
@@ -258,6 +346,33 @@ type FunctionDefinition struct {
 // Deprecated: use FunctionDefinition instead.
 type FunctionDefine = FunctionDefinition
 
+// FunctionDefinitionFor returns the definition of a function whose parameters
+// are the JSON encoding of T, as generated by jsonschema.GenerateSchemaForType.
+func FunctionDefinitionFor[T any](name, description string) (FunctionDefinition, error) {
+	var params T
+	schema, err := jsonschema.GenerateSchemaForType(params)
+	if err != nil {
+		return FunctionDefinition{}, err
+	}
+	return FunctionDefinition{Name: name, Description: description, Parameters: schema}, nil
+}
+
+// validateTools checks the definitions of the built-in tools of a request.
+func validateTools(tools []Tool) error {
+	for i, tool := range tools {
+		if tool.Type != ToolTypeRetrieval {
+			continue
+		}
+		if tool.Retrieval == nil {
+			return fmt.Errorf("tools[%d]: %w", i, ErrRetrievalMissingKnowledgeID)
+		}
+		if err := tool.Retrieval.Validate(); err != nil {
+			return fmt.Errorf("tools[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
 type TopLogProbs struct {
 	Token   string  `json:"token"`
 	LogProb float64 `json:"logprob"`
@@ -310,17 +425,21 @@ type ChatCompletionChoice struct {
 	// null: API response still in progress or incomplete
 	FinishReason FinishReason `json:"finish_reason"`
 	LogProbs     *LogProbs    `json:"logprobs,omitempty"`
+	// Retrieval lists the knowledge base chunks used by a retrieval tool.
+	Retrieval []RetrievalReference `json:"retrieval,omitempty"`
 }
 
 // ChatCompletionResponse represents a response structure for chat completion API.
 type ChatCompletionResponse struct {
 	ID                string                 `json:"id"`
+	RequestID         string                 `json:"request_id,omitempty"`
 	Object            string                 `json:"object"`
 	Created           int64                  `json:"created"`
 	Model             string                 `json:"model"`
 	Choices           []ChatCompletionChoice `json:"choices"`
 	Usage             Usage                  `json:"usage"`
 	SystemFingerprint string                 `json:"system_fingerprint"`
+	WebSearch         []WebSearchResult      `json:"web_search,omitempty"`
 
 	httpHeader
 }
@@ -341,6 +460,10 @@ func (c *Client) CreateChatCompletion(
 		return
 	}
 
+	if err = validateChatCompletionRequest(request); err != nil {
+		return
+	}
+
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
 	if err != nil {
 		return
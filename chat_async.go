@@ -0,0 +1,165 @@
+package zhipuai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	asyncChatCompletionsSuffix = "/async/chat/completions"
+	asyncResultSuffix          = "/async-result"
+)
+
+const (
+	defaultAsyncPollInterval    = time.Second
+	defaultAsyncPollMaxInterval = 10 * time.Second
+	defaultAsyncPollMultiplier  = 1.5
+)
+
+var ErrAsyncTaskFailed = errors.New("async task failed")
+
+// AsyncTaskStatus is the processing state of an asynchronous task.
+type AsyncTaskStatus string
+
+const (
+	AsyncTaskStatusProcessing AsyncTaskStatus = "PROCESSING"
+	AsyncTaskStatusSuccess    AsyncTaskStatus = "SUCCESS"
+	AsyncTaskStatusFail       AsyncTaskStatus = "FAIL"
+)
+
+// AsyncChatCompletionResponse is returned when an asynchronous chat completion is submitted.
+type AsyncChatCompletionResponse struct {
+	// ID is the task id to pass to RetrieveAsyncResult.
+	ID         string          `json:"id"`
+	RequestID  string          `json:"request_id"`
+	Model      string          `json:"model"`
+	TaskStatus AsyncTaskStatus `json:"task_status"`
+
+	httpHeader
+}
+
+// AsyncChatCompletionResult represents the state of an asynchronous chat completion.
+// Choices and Usage are only set once TaskStatus is AsyncTaskStatusSuccess.
+type AsyncChatCompletionResult struct {
+	ChatCompletionResponse
+	TaskStatus AsyncTaskStatus `json:"task_status"`
+}
+
+// AsyncPollOptions configures how WaitAsyncResult and WaitBatch poll.
+type AsyncPollOptions struct {
+	// Interval is the delay before the first poll. Defaults to one second.
+	Interval time.Duration
+	// MaxInterval caps the delay between polls. Defaults to ten seconds.
+	MaxInterval time.Duration
+	// Multiplier grows the delay after each poll. Defaults to 1.5.
+	Multiplier float64
+}
+
+func (o AsyncPollOptions) withDefaults() AsyncPollOptions {
+	if o.Interval <= 0 {
+		o.Interval = defaultAsyncPollInterval
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = defaultAsyncPollMaxInterval
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = o.Interval
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = defaultAsyncPollMultiplier
+	}
+	return o
+}
+
+// CreateAsyncChatCompletion — API call to submit a chat completion task.
+// The result is fetched later with RetrieveAsyncResult or WaitAsyncResult.
+func (c *Client) CreateAsyncChatCompletion(
+	ctx context.Context,
+	request ChatCompletionRequest,
+) (response AsyncChatCompletionResponse, err error) {
+	if request.Stream {
+		err = ErrChatCompletionStreamNotSupported
+		return
+	}
+
+	if !checkEndpointSupportsModel(chatCompletionsSuffix, request.Model) {
+		err = ErrChatCompletionInvalidModel
+		return
+	}
+
+	if err = validateChatCompletionRequest(request); err != nil {
+		return
+	}
+
+	urlSuffix := asyncChatCompletionsSuffix
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// RetrieveAsyncResult — API call to query the state of an asynchronous task.
+func (c *Client) RetrieveAsyncResult(
+	ctx context.Context,
+	id string,
+) (response AsyncChatCompletionResult, err error) {
+	urlSuffix := fmt.Sprintf("%s/%s", asyncResultSuffix, id)
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendRequest(req, &response)
+	return
+}
+
+// WaitAsyncResult polls an asynchronous task until it leaves the processing state
+// or ctx is done. A failed task is returned along with ErrAsyncTaskFailed.
+func (c *Client) WaitAsyncResult(
+	ctx context.Context,
+	id string,
+	opts AsyncPollOptions,
+) (response AsyncChatCompletionResult, err error) {
+	err = poll(ctx, opts, func() (bool, error) {
+		response, err = c.RetrieveAsyncResult(ctx, id)
+		if err != nil {
+			return false, err
+		}
+		switch response.TaskStatus {
+		case AsyncTaskStatusSuccess:
+			return true, nil
+		case AsyncTaskStatusFail:
+			return true, fmt.Errorf("%w: task %s", ErrAsyncTaskFailed, id)
+		default:
+			return false, nil
+		}
+	})
+	return
+}
+
+// poll calls check with a growing interval until it reports done, fails, or ctx is done.
+func poll(ctx context.Context, opts AsyncPollOptions, check func() (done bool, err error)) error {
+	opts = opts.withDefaults()
+	interval := opts.Interval
+	for {
+		if err := sleepContext(ctx, interval); err != nil {
+			return err
+		}
+
+		done, err := check()
+		if done || err != nil {
+			return err
+		}
+
+		interval = time.Duration(float64(interval) * opts.Multiplier)
+		if interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
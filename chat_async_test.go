@@ -0,0 +1,104 @@
+package zhipuai_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+var testAsyncPollOptions = zhipuai.AsyncPollOptions{
+	Interval:    time.Millisecond,
+	MaxInterval: 5 * time.Millisecond,
+}
+
+func TestCreateAsyncChatCompletion(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/async/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req zhipuai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "could not read request", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"id":"task-1","request_id":"req-1","model":%q,"task_status":"PROCESSING"}`, req.Model)
+	})
+
+	req := testChatCompletionRequest
+	req.Model = zhipuai.GLM4
+	resp, err := client.CreateAsyncChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "CreateAsyncChatCompletion error")
+	if resp.ID != "task-1" || resp.TaskStatus != zhipuai.AsyncTaskStatusProcessing || resp.Model != zhipuai.GLM4 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+
+	req.Stream = true
+	_, err = client.CreateAsyncChatCompletion(context.Background(), req)
+	checks.ErrorIs(t, err, zhipuai.ErrChatCompletionStreamNotSupported, "stream should be rejected")
+}
+
+func TestWaitAsyncResult(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	polls := 0
+	server.RegisterHandler("/v1/async-result/task-1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		polls++
+		if polls < 3 {
+			fmt.Fprintln(w, `{"id":"task-1","task_status":"PROCESSING"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"id":"task-1","model":"glm-4","task_status":"SUCCESS",`+
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"hi"}}],`+
+			`"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`)
+	})
+
+	resp, err := client.WaitAsyncResult(context.Background(), "task-1", testAsyncPollOptions)
+	checks.NoError(t, err, "WaitAsyncResult error")
+	if polls != 3 {
+		t.Errorf("expected 3 polls, got %d", polls)
+	}
+	if resp.TaskStatus != zhipuai.AsyncTaskStatusSuccess || resp.Choices[0].Message.Content != "hi" ||
+		resp.Usage.TotalTokens != 4 {
+		t.Errorf("unexpected result: %+v", resp)
+	}
+}
+
+func TestWaitAsyncResultFailed(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/async-result/task-1", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"id":"task-1","task_status":"FAIL"}`)
+	})
+
+	resp, err := client.WaitAsyncResult(context.Background(), "task-1", testAsyncPollOptions)
+	checks.ErrorIs(t, err, zhipuai.ErrAsyncTaskFailed, "failed task should be reported")
+	if resp.TaskStatus != zhipuai.AsyncTaskStatusFail {
+		t.Errorf("expected FAIL status, got %s", resp.TaskStatus)
+	}
+}
+
+func TestWaitAsyncResultContextDone(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/async-result/task-1", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"id":"task-1","task_status":"PROCESSING"}`)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := client.WaitAsyncResult(ctx, "task-1", testAsyncPollOptions)
+	checks.ErrorIs(t, err, context.DeadlineExceeded, "polling should stop when the context is done")
+}
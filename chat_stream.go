@@ -10,6 +10,8 @@ type ChatCompletionStreamChoiceDelta struct {
 	Role         string        `json:"role,omitempty"`
 	FunctionCall *FunctionCall `json:"function_call,omitempty"`
 	ToolCalls    []ToolCall    `json:"tool_calls,omitempty"`
+	// Retrieval lists the knowledge base chunks used by a retrieval tool.
+	Retrieval []RetrievalReference `json:"retrieval,omitempty"`
 }
 
 type ChatCompletionStreamChoice struct {
@@ -21,11 +23,15 @@ type ChatCompletionStreamChoice struct {
 
 type ChatCompletionStreamResponse struct {
 	ID                string                       `json:"id"`
+	RequestID         string                       `json:"request_id,omitempty"`
 	Object            string                       `json:"object"`
 	Created           int64                        `json:"created"`
 	Model             string                       `json:"model"`
 	Choices           []ChatCompletionStreamChoice `json:"choices"`
 	PromptAnnotations []PromptAnnotation           `json:"prompt_annotations,omitempty"`
+	WebSearch         []WebSearchResult            `json:"web_search,omitempty"`
+	// Usage is only set on the last chunk of the stream.
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // ChatCompletionStream
@@ -49,6 +55,10 @@ func (c *Client) CreateChatCompletionStream(
 	}
 
 	request.Stream = true
+	if err = validateChatCompletionRequest(request); err != nil {
+		return
+	}
+
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
 	if err != nil {
 		return nil, err
@@ -58,6 +68,24 @@ func (c *Client) CreateChatCompletionStream(
 	if err != nil {
 		return
 	}
+	if c.config.StreamResumeAttempts > 0 {
+		resp.resumesLeft = c.config.StreamResumeAttempts
+		resp.resume = func(prefix string) (*http.Response, error) {
+			resumed := request
+			if prefix != "" {
+				// Without any content received, the original request is sent again.
+				resumed.Messages = append(append([]ChatCompletionMessage(nil), request.Messages...), ChatCompletionMessage{
+					Role:    ChatMessageRoleAssistant,
+					Content: prefix,
+				})
+			}
+			req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(resumed))
+			if err != nil {
+				return nil, err
+			}
+			return c.openStream(req) //nolint:bodyclose // body is closed in stream.Close()
+		}
+	}
 	stream = &ChatCompletionStream{
 		streamReader: resp,
 	}
@@ -0,0 +1,168 @@
+package zhipuai
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxStreamChoices bounds the choice indexes accepted from a stream, so that a
+// malformed chunk cannot make the accumulated response grow without limit.
+const maxStreamChoices = 128
+
+var ErrStreamChoiceIndexInvalid = errors.New("stream chunk has an invalid choice index")
+
+// ChatCompletionAccumulator rebuilds the response of a streamed chat completion
+// from its chunks. Content and tool call arguments are concatenated per choice,
+// and tool call fragments are merged by their index.
+//
+// The zero value is ready to use.
+type ChatCompletionAccumulator struct {
+	response ChatCompletionResponse
+	// toolCalls maps, per choice, the index of a streamed tool call to its
+	// position in the message.
+	toolCalls map[int]map[int]int
+}
+
+// Add merges a chunk into the response. A chunk with a choice index that is
+// negative or not below 128 is rejected with ErrStreamChoiceIndexInvalid.
+func (a *ChatCompletionAccumulator) Add(chunk ChatCompletionStreamResponse) error {
+	for _, streamChoice := range chunk.Choices {
+		if streamChoice.Index < 0 || streamChoice.Index >= maxStreamChoices {
+			return fmt.Errorf("%w: %d", ErrStreamChoiceIndexInvalid, streamChoice.Index)
+		}
+	}
+
+	if a.response.ID == "" {
+		a.response.ID = chunk.ID
+	}
+	if a.response.Object == "" && chunk.Object != "" {
+		a.response.Object = strings.TrimSuffix(chunk.Object, ".chunk")
+	}
+	if a.response.RequestID == "" {
+		a.response.RequestID = chunk.RequestID
+	}
+	if a.response.Created == 0 {
+		a.response.Created = chunk.Created
+	}
+	if a.response.Model == "" {
+		a.response.Model = chunk.Model
+	}
+	if chunk.Usage != nil {
+		a.response.Usage = *chunk.Usage
+	}
+	a.response.WebSearch = append(a.response.WebSearch, chunk.WebSearch...)
+
+	for _, streamChoice := range chunk.Choices {
+		choice := a.choice(streamChoice.Index)
+		delta := streamChoice.Delta
+		if delta.Role != "" {
+			choice.Message.Role = delta.Role
+		}
+		choice.Message.Content += delta.Content
+		if delta.FunctionCall != nil {
+			if choice.Message.FunctionCall == nil {
+				choice.Message.FunctionCall = &FunctionCall{}
+			}
+			mergeFunctionCall(choice.Message.FunctionCall, *delta.FunctionCall)
+		}
+		for _, toolCall := range delta.ToolCalls {
+			a.addToolCall(choice, toolCall)
+		}
+		choice.Retrieval = append(choice.Retrieval, delta.Retrieval...)
+		if streamChoice.FinishReason != "" {
+			choice.FinishReason = streamChoice.FinishReason
+		}
+	}
+	return nil
+}
+
+// choice returns the choice with the given index, creating the missing ones.
+func (a *ChatCompletionAccumulator) choice(index int) *ChatCompletionChoice {
+	for len(a.response.Choices) <= index {
+		a.response.Choices = append(a.response.Choices, ChatCompletionChoice{Index: len(a.response.Choices)})
+	}
+	return &a.response.Choices[index]
+}
+
+func (a *ChatCompletionAccumulator) addToolCall(choice *ChatCompletionChoice, fragment ToolCall) {
+	message := &choice.Message
+	if fragment.Index == nil {
+		// Tool calls without an index are sent whole.
+		message.ToolCalls = append(message.ToolCalls, fragment)
+		return
+	}
+
+	if a.toolCalls == nil {
+		a.toolCalls = make(map[int]map[int]int)
+	}
+	positions, ok := a.toolCalls[choice.Index]
+	if !ok {
+		positions = make(map[int]int)
+		a.toolCalls[choice.Index] = positions
+	}
+	position, ok := positions[*fragment.Index]
+	if !ok {
+		position = len(message.ToolCalls)
+		positions[*fragment.Index] = position
+		message.ToolCalls = append(message.ToolCalls, ToolCall{})
+	}
+
+	toolCall := &message.ToolCalls[position]
+	if fragment.ID != "" {
+		toolCall.ID = fragment.ID
+	}
+	if fragment.Type != "" {
+		toolCall.Type = fragment.Type
+	}
+	mergeFunctionCall(&toolCall.Function, fragment.Function)
+}
+
+func mergeFunctionCall(call *FunctionCall, fragment FunctionCall) {
+	if fragment.Name != "" {
+		call.Name = fragment.Name
+	}
+	call.Arguments += fragment.Arguments
+}
+
+// Response returns the response accumulated so far, shaped like the response
+// of CreateChatCompletion.
+func (a *ChatCompletionAccumulator) Response() ChatCompletionResponse {
+	response := a.response
+	response.Choices = make([]ChatCompletionChoice, len(a.response.Choices))
+	for i, choice := range a.response.Choices {
+		if choice.Message.Role == "" {
+			choice.Message.Role = ChatMessageRoleAssistant
+		}
+		choice.Message.ToolCalls = append([]ToolCall(nil), choice.Message.ToolCalls...)
+		if choice.Message.FunctionCall != nil {
+			functionCall := *choice.Message.FunctionCall
+			choice.Message.FunctionCall = &functionCall
+		}
+		response.Choices[i] = choice
+	}
+	return response
+}
+
+// Collect reads the stream to its end and returns the accumulated response.
+// On error, the response holds the chunks received until then.
+func (stream *ChatCompletionStream) Collect() (ChatCompletionResponse, error) {
+	var accumulator ChatCompletionAccumulator
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err == nil {
+			err = accumulator.Add(chunk)
+		}
+		if err != nil {
+			return accumulator.Response(), err
+		}
+	}
+
+	response := accumulator.Response()
+	response.SetHeader(stream.Header())
+	return response, nil
+}
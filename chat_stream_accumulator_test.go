@@ -0,0 +1,121 @@
+package zhipuai_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestChatCompletionAccumulator(t *testing.T) {
+	chunks := []zhipuai.ChatCompletionStreamResponse{
+		{
+			ID: "1", Object: "chat.completion.chunk", Created: 1, Model: "glm-4",
+			Choices: []zhipuai.ChatCompletionStreamChoice{
+				{Index: 0, Delta: zhipuai.ChatCompletionStreamChoiceDelta{Role: "assistant", Content: "Hel"}},
+				{Index: 1, Delta: zhipuai.ChatCompletionStreamChoiceDelta{ToolCalls: []zhipuai.ToolCall{{
+					Index:    intPtr(0),
+					ID:       "call_1",
+					Type:     zhipuai.ToolTypeFunction,
+					Function: zhipuai.FunctionCall{Name: "get_weather", Arguments: `{"city":`},
+				}}}},
+			},
+		},
+		{
+			ID: "1", Object: "chat.completion.chunk", Created: 1, Model: "glm-4",
+			Choices: []zhipuai.ChatCompletionStreamChoice{
+				{Index: 0, Delta: zhipuai.ChatCompletionStreamChoiceDelta{Content: "lo"}, FinishReason: "stop"},
+				{Index: 1, Delta: zhipuai.ChatCompletionStreamChoiceDelta{ToolCalls: []zhipuai.ToolCall{
+					{Index: intPtr(0), Function: zhipuai.FunctionCall{Arguments: `"Beijing"}`}},
+					{Index: intPtr(1), ID: "call_2", Type: zhipuai.ToolTypeFunction,
+						Function: zhipuai.FunctionCall{Name: "get_time", Arguments: "{}"}},
+				}}, FinishReason: "tool_calls"},
+			},
+			Usage: &zhipuai.Usage{PromptTokens: 5, CompletionTokens: 7, TotalTokens: 12},
+		},
+	}
+
+	var accumulator zhipuai.ChatCompletionAccumulator
+	for _, chunk := range chunks {
+		checks.NoError(t, accumulator.Add(chunk), "Add error")
+	}
+
+	expected := zhipuai.ChatCompletionResponse{
+		ID: "1", Object: "chat.completion", Created: 1, Model: "glm-4",
+		Choices: []zhipuai.ChatCompletionChoice{
+			{
+				Index:        0,
+				Message:      zhipuai.ChatCompletionMessage{Role: "assistant", Content: "Hello"},
+				FinishReason: zhipuai.FinishReasonStop,
+			},
+			{
+				Index: 1,
+				Message: zhipuai.ChatCompletionMessage{Role: "assistant", ToolCalls: []zhipuai.ToolCall{
+					{ID: "call_1", Type: zhipuai.ToolTypeFunction,
+						Function: zhipuai.FunctionCall{Name: "get_weather", Arguments: `{"city":"Beijing"}`}},
+					{ID: "call_2", Type: zhipuai.ToolTypeFunction,
+						Function: zhipuai.FunctionCall{Name: "get_time", Arguments: "{}"}},
+				}},
+				FinishReason: zhipuai.FinishReasonToolCalls,
+			},
+		},
+		Usage: zhipuai.Usage{PromptTokens: 5, CompletionTokens: 7, TotalTokens: 12},
+	}
+	if got := accumulator.Response(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected response:\n got %+v\nwant %+v", got, expected)
+	}
+}
+
+func TestChatCompletionAccumulatorInvalidIndex(t *testing.T) {
+	var accumulator zhipuai.ChatCompletionAccumulator
+	for _, index := range []int{-1, 1 << 30} {
+		err := accumulator.Add(zhipuai.ChatCompletionStreamResponse{
+			Choices: []zhipuai.ChatCompletionStreamChoice{{Index: index}},
+		})
+		checks.ErrorIs(t, err, zhipuai.ErrStreamChoiceIndexInvalid, "invalid index should be rejected")
+	}
+	if choices := accumulator.Response().Choices; len(choices) != 0 {
+		t.Errorf("expected no choice to be added, got %d", len(choices))
+	}
+}
+
+func TestChatCompletionStreamCollect(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set(xCustomHeader, xCustomHeaderValue)
+		//nolint:lll
+		_, err := w.Write([]byte(`data: {"id":"1","model":"glm-4","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"}}]}
+
+data: {"id":"1","model":"glm-4","choices":[{"index":0,"delta":{"content":" there"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}
+
+data: [DONE]
+
+`))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	response, err := stream.Collect()
+	checks.NoError(t, err, "Collect error")
+	if response.Choices[0].Message.Content != "Hi there" || response.Choices[0].FinishReason != zhipuai.FinishReasonStop {
+		t.Errorf("unexpected choice: %+v", response.Choices[0])
+	}
+	if response.Usage.TotalTokens != 5 {
+		t.Errorf("expected usage to be captured, got %+v", response.Usage)
+	}
+	if response.Header().Get(xCustomHeader) != xCustomHeaderValue {
+		t.Errorf("expected stream headers on the response")
+	}
+}
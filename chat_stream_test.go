@@ -9,8 +9,10 @@ import (
 	"net/http"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test"
 	"github.com/bbang94/go-zhipuai/internal/test/checks"
 )
 
@@ -414,3 +416,230 @@ func compareChatStreamResponseChoices(c1, c2 zhipuai.ChatCompletionStreamChoice)
 	}
 	return true
 }
+
+func TestCreateChatCompletionStreamWebSearch(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		//nolint:lll
+		data := `{"id":"1","model":"glm-4","choices":[{"index":0,"delta":{"content":"Go"}}],"web_search":[{"title":"Go","link":"https://go.dev","refer":"ref_1"}]}`
+		_, err := w.Write([]byte("data: " + data + "\n\ndata: [DONE]\n\n"))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	chunk, err := stream.Recv()
+	checks.NoError(t, err, "stream.Recv() failed")
+	if len(chunk.WebSearch) != 1 || chunk.WebSearch[0].Refer != "ref_1" {
+		t.Errorf("unexpected web search results: %+v", chunk.WebSearch)
+	}
+}
+
+func TestCreateChatCompletionStreamRetrieval(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		//nolint:lll
+		data := `{"id":"1","model":"glm-4","choices":[{"index":0,"delta":{"content":"42","retrieval":[{"knowledge_id":"kb","document_id":"doc-1"}]}}]}`
+		_, err := w.Write([]byte("data: " + data + "\n\ndata: [DONE]\n\n"))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	chunk, err := stream.Recv()
+	checks.NoError(t, err, "stream.Recv() failed")
+	if refs := chunk.Choices[0].Delta.Retrieval; len(refs) != 1 || refs[0].DocumentID != "doc-1" {
+		t.Errorf("unexpected retrieval references: %+v", refs)
+	}
+}
+
+func TestCreateChatCompletionStreamUsage(t *testing.T) {
+	pool := zhipuai.NewKeyPool(test.GetTestToken())
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.CredentialProvider = pool
+	})
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		//nolint:lll
+		_, err := w.Write([]byte(`data: {"id":"1","choices":[{"index":0,"delta":{"content":"hi"}}]}
+
+data: {"id":"1","choices":[{"index":0,"delta":{},"finish_reason":"length"}],"usage":{"prompt_tokens":3,"completion_tokens":5,"total_tokens":8}}
+
+data: [DONE]
+
+`))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	if stream.Usage() != nil {
+		t.Errorf("expected no usage before the last chunk")
+	}
+	for {
+		_, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		checks.NoError(t, err, "stream.Recv() failed")
+	}
+
+	usage := stream.Usage()
+	if usage == nil || usage.TotalTokens != 8 || usage.CompletionTokens != 5 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+	if stream.FinishReason() != zhipuai.FinishReasonLength {
+		t.Errorf("expected finish reason length, got %q", stream.FinishReason())
+	}
+	if keyUsage := pool.Usage()[0]; keyUsage.TotalTokens != 8 {
+		t.Errorf("expected streamed usage to be reported to the key pool, got %+v", keyUsage)
+	}
+}
+
+func TestCreateChatCompletionStreamSSEFields(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err := w.Write([]byte(": keep-alive\n\n" +
+			"event: add\nid: 7\ndata:{\"id\":\"1\",\"choices\":[{\"index\":0,\n" +
+			"data: \"delta\":{\"content\":\"hi\"}}]}\n\n" +
+			"data: [DONE]\n\n"))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	chunk, err := stream.Recv()
+	checks.NoError(t, err, "stream.Recv() failed")
+	if chunk.Choices[0].Delta.Content != "hi" {
+		t.Errorf("expected multi-line data to be decoded, got %+v", chunk)
+	}
+	if stream.LastEvent() != "add" || stream.LastEventID() != "7" {
+		t.Errorf("unexpected event %q with id %q", stream.LastEvent(), stream.LastEventID())
+	}
+
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, io.EOF, "stream should end after [DONE]")
+}
+
+// handleStallingStream sends the given chunks, then stalls until the client goes away.
+func handleStallingStream(t *testing.T, w http.ResponseWriter, r *http.Request, chunks ...string) {
+	t.Helper()
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, chunk := range chunks {
+		_, err := w.Write([]byte("data: " + chunk + "\n\n"))
+		checks.NoError(t, err, "Write error")
+	}
+	w.(http.Flusher).Flush()
+	<-r.Context().Done()
+}
+
+func TestCreateChatCompletionStreamIdleTimeout(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.StreamIdleTimeout = 50 * time.Millisecond
+	})
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		handleStallingStream(t, w, r, `{"id":"1","choices":[{"index":0,"delta":{"content":"Hello"}}]}`)
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	checks.NoError(t, err, "stream.Recv() failed")
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, zhipuai.ErrStreamIdle, "stalled stream should time out")
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, zhipuai.ErrStreamIdle, "timed out stream should stay failed")
+}
+
+func TestCreateChatCompletionStreamResume(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.StreamIdleTimeout = 50 * time.Millisecond
+		config.StreamResumeAttempts = 1
+	})
+	defer teardown()
+	requests := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			handleStallingStream(t, w, r, `{"id":"1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"}}]}`)
+			return
+		}
+
+		req, err := getChatCompletionBody(r)
+		checks.NoError(t, err, "could not read request")
+		last := req.Messages[len(req.Messages)-1]
+		if len(req.Messages) != 2 || last.Role != zhipuai.ChatMessageRoleAssistant || last.Content != "Hello" {
+			http.Error(w, "expected the received text as assistant prefix", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err = w.Write([]byte(`data: {"id":"2","choices":[{"index":0,"delta":{"content":" world"},` +
+			`"finish_reason":"stop"}]}` + "\n\ndata: [DONE]\n\n"))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	response, err := stream.Collect()
+	checks.NoError(t, err, "stream should be resumed")
+	if content := response.Choices[0].Message.Content; content != "Hello world" || requests != 2 {
+		t.Errorf("expected %q after %d requests, got %q after %d", "Hello world", 2, content, requests)
+	}
+}
+
+func TestCreateChatCompletionStreamResumeWithoutContent(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.StreamIdleTimeout = 50 * time.Millisecond
+		config.StreamResumeAttempts = 1
+	})
+	defer teardown()
+	requests := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			handleStallingStream(t, w, r, `{"id":"1","choices":[{"index":0,"delta":{"role":"assistant"}}]}`)
+			return
+		}
+
+		req, err := getChatCompletionBody(r)
+		checks.NoError(t, err, "could not read request")
+		if len(req.Messages) != len(testChatCompletionRequest.Messages) {
+			http.Error(w, "expected the original request without prefix", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err = w.Write([]byte(`data: {"id":"2","choices":[{"index":0,"delta":{"content":"Hello"},` +
+			`"finish_reason":"stop"}]}` + "\n\ndata: [DONE]\n\n"))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	response, err := stream.Collect()
+	checks.NoError(t, err, "stream should be resumed with the original request")
+	if content := response.Choices[0].Message.Content; content != "Hello" || requests != 2 {
+		t.Errorf("expected %q after %d requests, got %q after %d", "Hello", 2, content, requests)
+	}
+}
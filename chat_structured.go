@@ -0,0 +1,107 @@
+package zhipuai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bbang94/go-zhipuai/jsonschema"
+)
+
+var (
+	ErrStructuredOutputNoChoice = errors.New("the chat completion has no choice to decode")
+	ErrStructuredOutputInvalid  = errors.New("the reply does not match the expected schema")
+)
+
+const structuredOutputPrompt = "Reply only with a JSON value matching the following JSON schema, " +
+	"without any explanation or code fence:\n"
+
+// CreateChatCompletionInto requests a chat completion whose reply is decoded into a T.
+// The JSON schema of T is appended to the system prompt, and the JSON object
+// response format is requested when T is a struct or a map. The reply is
+// validated against the schema once stripped of any Markdown code fence; when
+// it does not match, the model is told the error and asked again, up to
+// ClientConfig.StructuredOutputRetries times.
+//
+// The returned response is the last chat completion received.
+func CreateChatCompletionInto[T any](
+	ctx context.Context,
+	client *Client,
+	request ChatCompletionRequest,
+) (result T, response ChatCompletionResponse, err error) {
+	schema, err := jsonschema.GenerateSchemaForType(result)
+	if err != nil {
+		return
+	}
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return
+	}
+
+	request.Messages = withSystemPrompt(request.Messages, structuredOutputPrompt+string(schemaJSON))
+	if request.ResponseFormat == nil && schema.Type == jsonschema.Object {
+		request.ResponseFormat = &ChatCompletionResponseFormat{Type: ChatCompletionResponseFormatTypeJSONObject}
+	}
+
+	for attempt := 0; ; attempt++ {
+		response, err = client.CreateChatCompletion(ctx, request)
+		if err != nil {
+			return
+		}
+		if len(response.Choices) == 0 {
+			err = ErrStructuredOutputNoChoice
+			return
+		}
+
+		reply := response.Choices[0].Message
+		var decoded T
+		validationErr := jsonschema.VerifySchemaAndUnmarshal(*schema, []byte(stripCodeFence(reply.Content)), &decoded)
+		if validationErr == nil {
+			result = decoded
+			return
+		}
+		if attempt >= client.config.StructuredOutputRetries {
+			err = fmt.Errorf("%w: %v", ErrStructuredOutputInvalid, validationErr) //nolint:errorlint
+			return
+		}
+
+		request.Messages = append(request.Messages[:len(request.Messages):len(request.Messages)],
+			reply,
+			ChatCompletionMessage{
+				Role: ChatMessageRoleUser,
+				Content: fmt.Sprintf("Your reply is invalid: %v. Reply again only with a JSON value matching the schema.",
+					validationErr),
+			},
+		)
+	}
+}
+
+// withSystemPrompt returns a copy of the messages with prompt appended to the
+// leading system message, or added as one.
+func withSystemPrompt(messages []ChatCompletionMessage, prompt string) []ChatCompletionMessage {
+	if len(messages) > 0 && messages[0].Role == ChatMessageRoleSystem && len(messages[0].MultiContent) == 0 {
+		result := append([]ChatCompletionMessage(nil), messages...)
+		result[0].Content += "\n\n" + prompt
+		return result
+	}
+	system := ChatCompletionMessage{Role: ChatMessageRoleSystem, Content: prompt}
+	return append([]ChatCompletionMessage{system}, messages...)
+}
+
+// stripCodeFence returns the content of a reply wrapped in a Markdown code
+// fence, such as "```json\n{...}\n```", or the trimmed reply otherwise.
+func stripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
+	}
+	content = strings.TrimSuffix(content, "```")
+	if i := strings.IndexByte(content, '\n'); i >= 0 {
+		content = content[i+1:]
+	} else {
+		content = strings.TrimPrefix(content, "```")
+	}
+	return strings.TrimSpace(content)
+}
@@ -0,0 +1,101 @@
+package zhipuai_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+type testWeather struct {
+	City        string  `json:"city"`
+	Temperature float64 `json:"temperature"`
+	Unit        string  `json:"unit" enum:"celsius,fahrenheit"`
+}
+
+func handleStructuredReplies(
+	t *testing.T,
+	replies ...string,
+) (func(http.ResponseWriter, *http.Request), *[]zhipuai.ChatCompletionRequest) {
+	t.Helper()
+	var requests []zhipuai.ChatCompletionRequest
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, err := getChatCompletionBody(r)
+		checks.NoError(t, err, "could not read request")
+		reply := replies[len(requests)]
+		requests = append(requests, req)
+
+		resBytes, _ := json.Marshal(zhipuai.ChatCompletionResponse{
+			Choices: []zhipuai.ChatCompletionChoice{{Message: zhipuai.ChatCompletionMessage{
+				Role:    zhipuai.ChatMessageRoleAssistant,
+				Content: reply,
+			}}},
+		})
+		fmt.Fprintln(w, string(resBytes))
+	}, &requests
+}
+
+func TestCreateChatCompletionInto(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	handler, requests := handleStructuredReplies(t,
+		`{"city":"Beijing","temperature":"hot","unit":"celsius"}`,
+		"```json\n{\"city\":\"Beijing\",\"temperature\":25.5,\"unit\":\"celsius\"}\n```",
+	)
+	server.RegisterHandler("/v1/chat/completions", handler)
+
+	req := testChatCompletionRequest
+	req.Messages = []zhipuai.ChatCompletionMessage{
+		{Role: zhipuai.ChatMessageRoleSystem, Content: "You are a weather service."},
+		{Role: zhipuai.ChatMessageRoleUser, Content: "What is the weather in Beijing?"},
+	}
+	weather, resp, err := zhipuai.CreateChatCompletionInto[testWeather](context.Background(), client, req)
+	checks.NoError(t, err, "CreateChatCompletionInto error")
+	if weather != (testWeather{City: "Beijing", Temperature: 25.5, Unit: "celsius"}) {
+		t.Errorf("unexpected result: %+v", weather)
+	}
+	if !strings.HasPrefix(resp.Choices[0].Message.Content, "```json") {
+		t.Errorf("expected the last response to be returned, got %+v", resp)
+	}
+
+	if len(*requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(*requests))
+	}
+	first, second := (*requests)[0], (*requests)[1]
+	system := first.Messages[0].Content
+	if len(first.Messages) != 2 || !strings.HasPrefix(system, "You are a weather service.") ||
+		!strings.Contains(system, `"enum":["celsius","fahrenheit"]`) {
+		t.Errorf("expected the schema in the system prompt, got %q", system)
+	}
+	if first.ResponseFormat == nil || first.ResponseFormat.Type != zhipuai.ChatCompletionResponseFormatTypeJSONObject {
+		t.Errorf("expected the JSON object response format, got %+v", first.ResponseFormat)
+	}
+	retry := second.Messages[len(second.Messages)-1]
+	if len(second.Messages) != 4 || !strings.Contains(retry.Content, "$.temperature: expected number, got string") {
+		t.Errorf("expected the validation error to be sent back, got %+v", second.Messages)
+	}
+}
+
+func TestCreateChatCompletionIntoRetriesExhausted(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.StructuredOutputRetries = 1
+	})
+	defer teardown()
+	handler, requests := handleStructuredReplies(t, "It is sunny.", `{"city":"Beijing"}`)
+	server.RegisterHandler("/v1/chat/completions", handler)
+
+	weather, _, err := zhipuai.CreateChatCompletionInto[testWeather](
+		context.Background(), client, testChatCompletionRequest)
+	checks.ErrorIs(t, err, zhipuai.ErrStructuredOutputInvalid, "invalid replies should fail")
+	if len(*requests) != 2 || weather != (testWeather{}) {
+		t.Errorf("expected a zero result after 2 requests, got %+v after %d", weather, len(*requests))
+	}
+	if system := (*requests)[0].Messages[0]; system.Role != zhipuai.ChatMessageRoleSystem {
+		t.Errorf("expected a system message to be added, got %+v", system)
+	}
+}
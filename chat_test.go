@@ -527,3 +527,150 @@ func TestFinishReason(t *testing.T) {
 		}
 	}
 }
+
+func TestChatCompletionsWebSearch(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Tools []map[string]json.RawMessage `json:"tools"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "could not read request", http.StatusInternalServerError)
+			return
+		}
+		if len(req.Tools) != 1 || string(req.Tools[0]["type"]) != `"web_search"` ||
+			string(req.Tools[0]["web_search"]) != `{"enable":true,"search_query":"go","search_result":true}` {
+			http.Error(w, "unexpected tools", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, `{"id":"1","choices":[{"index":0,"message":{"role":"assistant","content":"Go [ref_1]"}}],`+
+			`"web_search":[{"title":"The Go Programming Language","link":"https://go.dev","refer":"ref_1"}]}`)
+	})
+
+	req := testChatCompletionRequest
+	req.Model = zhipuai.GLM4
+	req.Tools = []zhipuai.Tool{{
+		Type:      zhipuai.ToolTypeWebSearch,
+		WebSearch: &zhipuai.WebSearchDefinition{Enable: true, SearchQuery: "go", SearchResult: true},
+	}}
+	resp, err := client.CreateChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if len(resp.WebSearch) != 1 || resp.WebSearch[0].Link != "https://go.dev" || resp.WebSearch[0].Refer != "ref_1" {
+		t.Errorf("unexpected web search results: %+v", resp.WebSearch)
+	}
+}
+
+func TestRetrievalDefinitionValidate(t *testing.T) {
+	cases := []struct {
+		name       string
+		definition zhipuai.RetrievalDefinition
+		err        error
+	}{
+		{"default template", zhipuai.RetrievalDefinition{KnowledgeId: "kb"}, nil},
+		{"custom template", zhipuai.RetrievalDefinition{
+			KnowledgeId:    "kb",
+			PromptTemplate: "Answer {{question}} using {{knowledge}}",
+		}, nil},
+		{"missing knowledge id", zhipuai.RetrievalDefinition{}, zhipuai.ErrRetrievalMissingKnowledgeID},
+		{"missing placeholder", zhipuai.RetrievalDefinition{
+			KnowledgeId:    "kb",
+			PromptTemplate: "Answer {{question}}",
+		}, zhipuai.ErrRetrievalTemplatePlaceholder},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.definition.Validate()
+			if !errors.Is(err, tc.err) {
+				t.Errorf("expected %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestChatCompletionsRetrieval(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"id":"1","choices":[{"index":0,"message":{"role":"assistant","content":"42"},`+
+			`"retrieval":[{"knowledge_id":"kb","document_id":"doc-1","content":"The answer is 42.","score":0.9}]}]}`)
+	})
+
+	req := testChatCompletionRequest
+	req.Model = zhipuai.GLM4
+	req.Tools = []zhipuai.Tool{{Type: zhipuai.ToolTypeRetrieval, Retrieval: &zhipuai.RetrievalDefinition{
+		KnowledgeId:    "kb",
+		PromptTemplate: "{{question}}",
+	}}}
+	_, err := client.CreateChatCompletion(context.Background(), req)
+	checks.ErrorIs(t, err, zhipuai.ErrRetrievalTemplatePlaceholder, "invalid template should be rejected")
+
+	req.Tools[0].Retrieval.PromptTemplate = ""
+	resp, err := client.CreateChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	refs := resp.Choices[0].Retrieval
+	if len(refs) != 1 || refs[0].DocumentID != "doc-1" || refs[0].Score != 0.9 {
+		t.Errorf("unexpected retrieval references: %+v", refs)
+	}
+}
+
+func TestFunctionDefinitionFor(t *testing.T) {
+	type weatherParams struct {
+		City string `json:"city" description:"The city name"`
+		Unit string `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+	}
+	def, err := zhipuai.FunctionDefinitionFor[weatherParams]("get_weather", "Get the weather")
+	checks.NoError(t, err, "FunctionDefinitionFor error")
+
+	params, ok := def.Parameters.(*jsonschema.Definition)
+	if !ok || def.Name != "get_weather" || def.Description != "Get the weather" {
+		t.Fatalf("unexpected definition: %+v", def)
+	}
+	if params.Type != jsonschema.Object || len(params.Required) != 1 || params.Required[0] != "city" ||
+		params.Properties["city"].Description != "The city name" || len(params.Properties["unit"].Enum) != 2 {
+		t.Errorf("unexpected parameters: %+v", params)
+	}
+
+	_, err = zhipuai.FunctionDefinitionFor[chan int]("f", "")
+	checks.ErrorIs(t, err, jsonschema.ErrUnsupportedType, "channels cannot be parameters")
+}
+
+func TestChatCompletionsGLMParams(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		req, err := getChatCompletionBody(r)
+		checks.NoError(t, err, "could not read request")
+		if req.RequestID == "fail" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"code":"1214","message":"invalid"},"request_id":"fail"}`)
+			return
+		}
+		if req.DoSample == nil || *req.DoSample || req.UserID != "user-42" || req.ToolChoice != zhipuai.ToolChoiceAuto {
+			http.Error(w, "unexpected parameters", http.StatusBadRequest)
+			return
+		}
+		resBytes, _ := json.Marshal(zhipuai.ChatCompletionResponse{RequestID: req.RequestID})
+		fmt.Fprintln(w, string(resBytes))
+	})
+
+	doSample := false
+	req := testChatCompletionRequest
+	req.Model = zhipuai.GLM4
+	req.RequestID = "req-1"
+	req.DoSample = &doSample
+	req.UserID = "user-42"
+	req.ToolChoice = zhipuai.ToolChoiceAuto
+	resp, err := client.CreateChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if resp.RequestID != "req-1" {
+		t.Errorf("expected the request id in the response, got %q", resp.RequestID)
+	}
+
+	req.RequestID = "fail"
+	_, err = client.CreateChatCompletion(context.Background(), req)
+	var apiErr *zhipuai.APIError
+	if !errors.As(err, &apiErr) || apiErr.RequestID != "fail" {
+		t.Errorf("expected the request id in the API error, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package zhipuai
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// MaxImagePartSize is the largest image accepted by GLM-4V models.
+const MaxImagePartSize = 5 << 20
+
+var (
+	ErrImagePartTooLarge        = errors.New("image exceeds the maximum size of a chat message part")
+	ErrImagePartUnsupportedType = errors.New("image format is not supported, use JPEG or PNG")
+	ErrModelVisionNotSupported  = errors.New("this model does not support image parts")
+	ErrModelVideoNotSupported   = errors.New("this model does not support video parts")
+)
+
+var imagePartTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+}
+
+// NewTextPart returns a text part of a multimodal message.
+func NewTextPart(text string) ChatMessagePart {
+	return ChatMessagePart{Type: ChatMessagePartTypeText, Text: text}
+}
+
+// NewImageURLPart returns an image part referencing an image by URL.
+func NewImageURLPart(url string) ChatMessagePart {
+	return ChatMessagePart{Type: ChatMessagePartTypeImageURL, ImageURL: &ChatMessageImageURL{URL: url}}
+}
+
+// NewVideoURLPart returns a video part referencing a video by URL.
+func NewVideoURLPart(url string) ChatMessagePart {
+	return ChatMessagePart{Type: ChatMessagePartTypeVideoURL, VideoURL: &ChatMessageVideoURL{URL: url}}
+}
+
+// NewImagePartFromReader returns an image part embedding the image read from r
+// as a base64 data URL. The format is sniffed from the content and must be
+// JPEG or PNG, and the image must not exceed MaxImagePartSize.
+func NewImagePartFromReader(r io.Reader) (ChatMessagePart, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxImagePartSize+1))
+	if err != nil {
+		return ChatMessagePart{}, err
+	}
+	if len(data) > MaxImagePartSize {
+		return ChatMessagePart{}, ErrImagePartTooLarge
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !imagePartTypes[mimeType] {
+		return ChatMessagePart{}, fmt.Errorf("%w: %s", ErrImagePartUnsupportedType, mimeType)
+	}
+	return NewImageURLPart("data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// NewImagePartFromFile is like NewImagePartFromReader for a local file.
+func NewImagePartFromFile(path string) (ChatMessagePart, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ChatMessagePart{}, err
+	}
+	defer file.Close()
+	return NewImagePartFromReader(file)
+}
+
+func (p ChatMessagePart) validate() error {
+	switch {
+	case p.Type == ChatMessagePartTypeImageURL && (p.ImageURL == nil || p.ImageURL.URL == ""),
+		p.Type == ChatMessagePartTypeVideoURL && (p.VideoURL == nil || p.VideoURL.URL == ""):
+		return fmt.Errorf("%w: %s", ErrChatMessagePartInvalid, p.Type)
+	default:
+		return nil
+	}
+}
+
+// validateMessageParts checks that image and video parts are only sent to
+// models supporting them.
+func validateMessageParts(messages []ChatCompletionMessage, capabilities ModelCapabilities) error {
+	for _, message := range messages {
+		for _, part := range message.MultiContent {
+			switch {
+			case part.Type == ChatMessagePartTypeImageURL && !capabilities.SupportsVision:
+				return ErrModelVisionNotSupported
+			case part.Type == ChatMessagePartTypeVideoURL && !capabilities.SupportsVideo:
+				return ErrModelVideoNotSupported
+			}
+		}
+	}
+	return nil
+}
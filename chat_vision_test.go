@@ -0,0 +1,113 @@
+package zhipuai_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestNewImagePartFromReader(t *testing.T) {
+	part, err := zhipuai.NewImagePartFromReader(bytes.NewReader(testPNG))
+	checks.NoError(t, err, "NewImagePartFromReader error")
+	if part.Type != zhipuai.ChatMessagePartTypeImageURL ||
+		!strings.HasPrefix(part.ImageURL.URL, "data:image/png;base64,iVBORw0KGgo") {
+		t.Errorf("unexpected part: %+v", part.ImageURL)
+	}
+
+	_, err = zhipuai.NewImagePartFromReader(strings.NewReader("GIF89a"))
+	checks.ErrorIs(t, err, zhipuai.ErrImagePartUnsupportedType, "GIF images should be rejected")
+
+	large := append(append([]byte(nil), testPNG...), make([]byte, zhipuai.MaxImagePartSize)...)
+	_, err = zhipuai.NewImagePartFromReader(bytes.NewReader(large))
+	checks.ErrorIs(t, err, zhipuai.ErrImagePartTooLarge, "large images should be rejected")
+}
+
+func TestNewImagePartFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.png")
+	checks.NoError(t, os.WriteFile(path, testPNG, 0o600), "WriteFile error")
+
+	part, err := zhipuai.NewImagePartFromFile(path)
+	checks.NoError(t, err, "NewImagePartFromFile error")
+	if !strings.HasPrefix(part.ImageURL.URL, "data:image/png;base64,") {
+		t.Errorf("unexpected part: %+v", part.ImageURL)
+	}
+
+	_, err = zhipuai.NewImagePartFromFile(filepath.Join(t.TempDir(), "missing.png"))
+	checks.ErrorIs(t, err, os.ErrNotExist, "missing files should fail")
+}
+
+func TestChatMessagePartMarshalValidation(t *testing.T) {
+	message := zhipuai.ChatCompletionMessage{
+		Role: zhipuai.ChatMessageRoleUser,
+		MultiContent: []zhipuai.ChatMessagePart{
+			zhipuai.NewTextPart("What happens?"),
+			zhipuai.NewVideoURLPart("https://example.com/video.mp4"),
+		},
+	}
+	data, err := json.Marshal(message)
+	checks.NoError(t, err, "Marshal error")
+	want := `{"role":"user","content":[{"type":"text","text":"What happens?"},` +
+		`{"type":"video_url","video_url":{"url":"https://example.com/video.mp4"}}]}`
+	if string(data) != want {
+		t.Errorf("unexpected message: %s", data)
+	}
+
+	message.MultiContent[1] = zhipuai.ChatMessagePart{Type: zhipuai.ChatMessagePartTypeImageURL}
+	_, err = json.Marshal(message)
+	if !errors.Is(err, zhipuai.ErrChatMessagePartInvalid) {
+		t.Errorf("expected an image part without URL to be rejected, got %v", err)
+	}
+}
+
+func TestChatCompletionVisionValidation(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", handleChatCompletionEndpoint)
+
+	req := zhipuai.ChatCompletionRequest{
+		Model: zhipuai.GLM4,
+		Messages: []zhipuai.ChatCompletionMessage{{
+			Role: zhipuai.ChatMessageRoleUser,
+			MultiContent: []zhipuai.ChatMessagePart{
+				zhipuai.NewTextPart("Describe it."),
+				zhipuai.NewImageURLPart("https://example.com/image.png"),
+			},
+		}},
+	}
+	_, err := client.CreateChatCompletion(context.Background(), req)
+	checks.ErrorIs(t, err, zhipuai.ErrModelVisionNotSupported, "images should be rejected for glm-4")
+
+	req.Model = zhipuai.GLM4V
+	_, err = client.CreateChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "images should be accepted by glm-4v")
+
+	req.Messages[0].MultiContent[1] = zhipuai.NewVideoURLPart("https://example.com/video.mp4")
+	_, err = client.CreateChatCompletion(context.Background(), req)
+	checks.ErrorIs(t, err, zhipuai.ErrModelVideoNotSupported, "videos should be rejected for glm-4v")
+
+	req.Model = zhipuai.GLM4VPlus
+	_, err = client.CreateChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "videos should be accepted by glm-4v-plus")
+}
+
+func TestChatCompletionVisionStreamValidation(t *testing.T) {
+	req := zhipuai.ChatCompletionRequest{
+		Model: zhipuai.CharGLM3,
+		Messages: []zhipuai.ChatCompletionMessage{{
+			Role:         zhipuai.ChatMessageRoleUser,
+			MultiContent: []zhipuai.ChatMessagePart{zhipuai.NewImageURLPart("https://example.com/image.png")},
+		}},
+	}
+	_, err := newOfflineClient().CreateChatCompletionStream(context.Background(), req)
+	checks.ErrorIs(t, err, zhipuai.ErrModelVisionNotSupported, "images should be rejected for charglm-3")
+}
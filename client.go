@@ -4,14 +4,10 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
-	"github.com/patrickmn/go-cache"
 	"io"
 	"net/http"
 	"strings"
-	"time"
 
 	utils "github.com/bbang94/go-zhipuai/internal"
 )
@@ -19,7 +15,8 @@ import (
 // Client is zhipuai GPT-3 API client.
 type Client struct {
 	config            ClientConfig
-	cache             *cache.Cache
+	credentials       CredentialProvider
+	limiter           *rateLimiter
 	requestBuilder    utils.RequestBuilder
 	createFormBuilder func(io.Writer) utils.FormBuilder
 }
@@ -51,12 +48,14 @@ func NewClient(authToken string) *Client {
 
 // NewClientWithConfig creates new zhipuai API client for specified config.
 func NewClientWithConfig(config ClientConfig) *Client {
-	cacheClient := cache.New(
-		time.Duration(CacheTTLSeconds)*time.Second,
-		time.Duration(CacheTTLSeconds)*time.Second)
+	credentials := config.CredentialProvider
+	if credentials == nil {
+		credentials = defaultCredentials(config, newTokenCache())
+	}
 	return &Client{
-		cache:          cacheClient,
+		credentials:    credentials,
 		config:         config,
+		limiter:        newRateLimiter(config.RateLimit),
 		requestBuilder: utils.NewRequestBuilder(),
 		createFormBuilder: func(body io.Writer) utils.FormBuilder {
 			return utils.NewFormBuilder(body)
@@ -107,11 +106,14 @@ func (c *Client) newRequest(ctx context.Context, method, url string, setters ...
 	for _, setter := range setters {
 		setter(args)
 	}
+	ctx = c.withRequestInfo(ctx, url, args.body)
 	req, err := c.requestBuilder.Build(ctx, method, url, args.body, args.header)
 	if err != nil {
 		return nil, err
 	}
-	c.setCommonHeaders(req)
+	if err = c.setCommonHeaders(req); err != nil {
+		return nil, err
+	}
 	return req, nil
 }
 
@@ -125,7 +127,7 @@ func (c *Client) sendRequest(req *http.Request, v Response) error {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	res, err := c.config.HTTPClient.Do(req)
+	res, err := c.doRequest(req, false)
 	if err != nil {
 		return err
 	}
@@ -140,11 +142,16 @@ func (c *Client) sendRequest(req *http.Request, v Response) error {
 		v.SetHeader(res.Header)
 	}
 
-	return decodeResponse(res.Body, v)
+	err = decodeResponse(res.Body, v)
+	if err == nil {
+		c.limiter.settle(estimateTokens(req), v)
+		c.observeUsage(res, v)
+	}
+	return err
 }
 
 func (c *Client) sendRequestRaw(req *http.Request) (body io.ReadCloser, err error) {
-	resp, err := c.config.HTTPClient.Do(req)
+	resp, err := c.doRequest(req, false)
 	if err != nil {
 		return
 	}
@@ -157,86 +164,59 @@ func (c *Client) sendRequestRaw(req *http.Request) (body io.ReadCloser, err erro
 }
 
 func sendRequestStream[T streamable](client *Client, req *http.Request) (*streamReader[T], error) {
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "text/event-stream")
-	req.Header.Set("Cache-Control", "no-cache")
-	req.Header.Set("Connection", "keep-alive")
-
-	resp, err := client.config.HTTPClient.Do(req) //nolint:bodyclose // body is closed in stream.Close()
+	resp, err := client.openStream(req) //nolint:bodyclose // body is closed in stream.Close()
 	if err != nil {
 		return new(streamReader[T]), err
 	}
-	if isFailureStatusCode(resp) {
-		return new(streamReader[T]), client.handleErrorResp(resp)
-	}
+	estimated := estimateTokens(req)
 	return &streamReader[T]{
 		emptyMessagesLimit: client.config.EmptyMessagesLimit,
+		idleTimeout:        client.config.StreamIdleTimeout,
 		reader:             bufio.NewReader(resp.Body),
 		response:           resp,
 		errAccumulator:     utils.NewErrorAccumulator(),
 		unmarshaler:        &utils.JSONUnmarshaler{},
 		httpHeader:         httpHeader(resp.Header),
+		onUsage: func(resp *http.Response, usage Usage) {
+			client.limiter.settleUsage(estimated, usage)
+			client.observeStreamUsage(resp.Request, usage)
+		},
 	}, nil
 }
 
-const (
-	APITokenTTLSeconds = 3 * 60
-	CacheTTLSeconds    = APITokenTTLSeconds - 30
-)
-
-type CustomClaims struct {
-	ApiKey    string `json:"api_key"`
-	Timestamp int64  `json:"timestamp"`
-	jwt.StandardClaims
-}
+func (c *Client) openStream(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("Connection", "keep-alive")
 
-func (c *Client) generateToken(apiKey string) (string, error) {
-	apiKeys := strings.Split(apiKey, ".")
-	if len(apiKeys) <= 1 {
-		return "", errors.New("invalid api key")
-	}
-	key, secret := apiKeys[0], apiKeys[1]
-	curTime := time.Now().UnixNano() / 1e6
-	claims := &CustomClaims{
-		ApiKey:         key,
-		Timestamp:      curTime,
-		StandardClaims: jwt.StandardClaims{ExpiresAt: curTime + APITokenTTLSeconds*1000},
+	resp, err := c.doRequest(req, true)
+	if err != nil {
+		return nil, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	token.Header = map[string]interface{}{
-		"alg":       "HS256",
-		"sign_type": "SIGN",
+	if isFailureStatusCode(resp) {
+		return nil, c.handleErrorResp(resp)
 	}
-	return token.SignedString([]byte(secret))
+	return resp, nil
 }
 
-func (c *Client) getToken() (string, error) {
-	// 获取缓存信息
-	cacheData, exist := c.cache.Get(c.config.authToken)
-	if exist {
-		token := cacheData.(string)
-		return token, nil
+func (c *Client) setCommonHeaders(req *http.Request) error {
+	if c.credentials == nil {
+		return nil
 	}
-
-	// 缓存信息不存在，则调用接口获取
-	token, err := c.generateToken(c.config.authToken)
+	token, err := c.credentials.Token(req.Context())
 	if err != nil {
-		return "", errors.New("generate token error")
+		return fmt.Errorf("error, generating credentials: %w", err)
 	}
-
-	cacheDuration := CacheTTLSeconds
-	c.cache.Set(c.config.authToken, token, time.Duration(cacheDuration)*time.Second)
-	return token, nil
-}
-
-func (c *Client) setCommonHeaders(req *http.Request) {
-	if c.config.authToken != "" {
-		token, err := c.getToken()
-		if err != nil {
-			return
-		}
+	if token == "" {
+		return nil
+	}
+	if c.config.APIType == APITypeAzure {
+		req.Header.Set(AzureAPIKeyHeader, token)
+	} else {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	}
+	return nil
 }
 
 func isFailureStatusCode(resp *http.Response) bool {
@@ -311,6 +291,7 @@ func (c *Client) handleErrorResp(resp *http.Response) error {
 	}
 
 	errRes.Error.HTTPStatusCode = resp.StatusCode
+	errRes.setRequestID()
 	return errRes.Error
 }
 
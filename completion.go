@@ -61,6 +61,20 @@ const (
 	GPT3Babbage002        = "babbage-002"
 )
 
+// GLM Defines the chat models provided by ZhipuAI.
+// See ModelCapabilities for the limits and features of each model.
+const (
+	GLM4      = "glm-4"
+	GLM4Plus  = "glm-4-plus"
+	GLM4Air   = "glm-4-air"
+	GLM4Flash = "glm-4-flash"
+	GLM4Long  = "glm-4-long"
+	GLM4V     = "glm-4v"
+	GLM4VPlus = "glm-4v-plus"
+	CharGLM3  = "charglm-3"
+	CodeGeeX4 = "codegeex-4"
+)
+
 // Codex Defines the models provided by zhipuai.
 // These models are designed for code-specific tasks, and use
 // a different tokenizer which optimizes for whitespace.
@@ -110,7 +124,13 @@ var disabledModelsForEndpoints = map[string]map[string]bool{
 }
 
 func checkEndpointSupportsModel(endpoint, model string) bool {
-	return !disabledModelsForEndpoints[endpoint][model]
+	if disabledModelsForEndpoints[endpoint][model] {
+		return false
+	}
+	if capabilities, ok := LookupModel(model); ok {
+		return capabilities.SupportsEndpoint(endpoint)
+	}
+	return true
 }
 
 func checkPromptType(prompt any) bool {
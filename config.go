@@ -3,12 +3,15 @@ package zhipuai
 import (
 	"net/http"
 	"regexp"
+	"time"
 )
 
 const (
 	zhipuaiAPIURLv1                = "https://open.bigmodel.cn/api/paas/v4"
 	defaultEmptyMessagesLimit uint = 300
 
+	defaultStructuredOutputRetries = 2
+
 	azureAPIPrefix         = "zhipuai"
 	azureDeploymentsPrefix = "deployments"
 )
@@ -33,8 +36,27 @@ type ClientConfig struct {
 	APIVersion           string                    // required when APIType is APITypeAzure or APITypeAzureAD
 	AzureModelMapperFunc func(model string) string // replace model to azure deployment name func
 	HTTPClient           *http.Client
+	// CredentialProvider supplies the request credentials. When nil, the key passed to
+	// DefaultConfig is signed into a JWT if it has the "id.secret" format and sent verbatim otherwise.
+	CredentialProvider CredentialProvider
+	// RetryPolicy controls how transient failures are retried. The zero value disables retries.
+	RetryPolicy RetryPolicy
+	// RateLimit enables a client-side limiter on requests and tokens per minute.
+	RateLimit RateLimit
+	// Middlewares wrap every outgoing request, the first one being the outermost.
+	Middlewares []Middleware
 
 	EmptyMessagesLimit uint
+	// StreamIdleTimeout fails a stream with ErrStreamIdle when no message is received
+	// for this long. Zero disables the timeout.
+	StreamIdleTimeout time.Duration
+	// StreamResumeAttempts is how many times an interrupted chat completion stream is
+	// requested again, with the text received so far sent as the beginning of the
+	// assistant reply. Zero disables resuming.
+	StreamResumeAttempts int
+	// StructuredOutputRetries is how many times CreateChatCompletionInto asks the model
+	// again when its reply does not match the expected schema.
+	StructuredOutputRetries int
 }
 
 func DefaultConfig(authToken string) ClientConfig {
@@ -46,7 +68,8 @@ func DefaultConfig(authToken string) ClientConfig {
 
 		HTTPClient: &http.Client{},
 
-		EmptyMessagesLimit: defaultEmptyMessagesLimit,
+		EmptyMessagesLimit:      defaultEmptyMessagesLimit,
+		StructuredOutputRetries: defaultStructuredOutputRetries,
 	}
 }
 
@@ -63,7 +86,8 @@ func DefaultAzureConfig(apiKey, baseURL string) ClientConfig {
 
 		HTTPClient: &http.Client{},
 
-		EmptyMessagesLimit: defaultEmptyMessagesLimit,
+		EmptyMessagesLimit:      defaultEmptyMessagesLimit,
+		StructuredOutputRetries: defaultStructuredOutputRetries,
 	}
 }
 
@@ -0,0 +1,210 @@
+package zhipuai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	APITokenTTLSeconds = 3 * 60
+	CacheTTLSeconds    = APITokenTTLSeconds - 30
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key, expected the \"id.secret\" format")
+	ErrMissingAPIKey = errors.New("api key is empty")
+)
+
+// CredentialProvider supplies the credential sent with every request, either as
+// "Authorization: Bearer <token>" or, for Azure, as the api-key header.
+// An empty token with a nil error sends the request without credentials.
+type CredentialProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// CredentialFunc adapts an ordinary function to a CredentialProvider.
+type CredentialFunc func(ctx context.Context) (string, error)
+
+func (f CredentialFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticCredentials sends the API key verbatim as a bearer token.
+type StaticCredentials string
+
+func (s StaticCredentials) Token(context.Context) (string, error) {
+	return string(s), nil
+}
+
+// KeySource returns the current API key. It is called for every request,
+// so that rotated keys are picked up without recreating the client.
+type KeySource func(ctx context.Context) (string, error)
+
+// StaticKey returns a KeySource that always yields key.
+func StaticKey(key string) KeySource {
+	return func(context.Context) (string, error) {
+		return key, nil
+	}
+}
+
+// EnvKey returns a KeySource that reads the key from the environment variable name.
+func EnvKey(name string) KeySource {
+	return func(context.Context) (string, error) {
+		key := strings.TrimSpace(os.Getenv(name))
+		if key == "" {
+			return "", fmt.Errorf("%w: environment variable %s is not set", ErrMissingAPIKey, name)
+		}
+		return key, nil
+	}
+}
+
+// FileKey returns a KeySource that reads the key from the file at path. The file
+// is read again whenever its modification time changes.
+func FileKey(path string) KeySource {
+	var (
+		mu      sync.Mutex
+		key     string
+		modTime time.Time
+	)
+	return func(context.Context) (string, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if key != "" && info.ModTime().Equal(modTime) {
+			return key, nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			return "", fmt.Errorf("%w: file %s is empty", ErrMissingAPIKey, path)
+		}
+		key, modTime = string(data), info.ModTime()
+		return key, nil
+	}
+}
+
+type CustomClaims struct {
+	ApiKey    string `json:"api_key"`
+	Timestamp int64  `json:"timestamp"`
+	jwt.StandardClaims
+}
+
+// JWTCredentials signs ZhipuAI "id.secret" API keys into short-lived HS256 tokens.
+// Signed tokens are cached per key and renewed ClockSkew before they expire.
+type JWTCredentials struct {
+	Key KeySource
+	// TTL is the lifetime of a signed token. Defaults to APITokenTTLSeconds.
+	TTL time.Duration
+	// ClockSkew is how long before expiry a cached token is renewed, to absorb
+	// clock differences with the server. Defaults to 30 seconds.
+	ClockSkew time.Duration
+
+	cacheOnce sync.Once
+	cache     *cache.Cache
+}
+
+// NewJWTCredentials creates a JWT signer with the default TTL and clock skew.
+func NewJWTCredentials(key KeySource) *JWTCredentials {
+	return newJWTCredentials(key, newTokenCache())
+}
+
+func newJWTCredentials(key KeySource, tokenCache *cache.Cache) *JWTCredentials {
+	return &JWTCredentials{
+		Key:       key,
+		TTL:       APITokenTTLSeconds * time.Second,
+		ClockSkew: (APITokenTTLSeconds - CacheTTLSeconds) * time.Second,
+		cache:     tokenCache,
+	}
+}
+
+func newTokenCache() *cache.Cache {
+	return cache.New(
+		time.Duration(CacheTTLSeconds)*time.Second,
+		time.Duration(CacheTTLSeconds)*time.Second)
+}
+
+func (j *JWTCredentials) Token(ctx context.Context) (string, error) {
+	apiKey, err := j.Key(ctx)
+	if err != nil {
+		return "", err
+	}
+	return j.token(apiKey)
+}
+
+// token returns the cached token for apiKey, signing a new one when needed.
+func (j *JWTCredentials) token(apiKey string) (string, error) {
+	j.cacheOnce.Do(func() {
+		if j.cache == nil {
+			j.cache = newTokenCache()
+		}
+	})
+	if cached, ok := j.cache.Get(apiKey); ok {
+		if token, isString := cached.(string); isString {
+			return token, nil
+		}
+	}
+
+	ttl := j.TTL
+	if ttl <= 0 {
+		ttl = APITokenTTLSeconds * time.Second
+	}
+	token, err := generateToken(apiKey, ttl)
+	if err != nil {
+		return "", err
+	}
+
+	skew := j.ClockSkew
+	if skew <= 0 {
+		skew = (APITokenTTLSeconds - CacheTTLSeconds) * time.Second
+	}
+	if cacheDuration := ttl - skew; cacheDuration > 0 {
+		j.cache.Set(apiKey, token, cacheDuration)
+	}
+	return token, nil
+}
+
+func generateToken(apiKey string, ttl time.Duration) (string, error) {
+	key, secret, found := strings.Cut(apiKey, ".")
+	if !found || key == "" || secret == "" {
+		return "", ErrInvalidAPIKey
+	}
+	curTime := time.Now().UnixNano() / 1e6
+	claims := &CustomClaims{
+		ApiKey:         key,
+		Timestamp:      curTime,
+		StandardClaims: jwt.StandardClaims{ExpiresAt: curTime + ttl.Milliseconds()},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header = map[string]interface{}{
+		"alg":       "HS256",
+		"sign_type": "SIGN",
+	}
+	return token.SignedString([]byte(secret))
+}
+
+// defaultCredentials picks the provider used when ClientConfig.CredentialProvider
+// is not set: keys in the ZhipuAI "id.secret" format are signed into JWTs, any
+// other key is sent verbatim.
+func defaultCredentials(config ClientConfig, tokenCache *cache.Cache) CredentialProvider {
+	isAzure := config.APIType == APITypeAzure || config.APIType == APITypeAzureAD
+	if !isAzure && strings.Contains(config.authToken, ".") {
+		return newJWTCredentials(StaticKey(config.authToken), tokenCache)
+	}
+	return StaticCredentials(config.authToken)
+}
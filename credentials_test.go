@@ -0,0 +1,139 @@
+package zhipuai_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+const testJWTKey = "my-key-id.my-key-secret"
+
+func TestJWTCredentialsSignsAndCaches(t *testing.T) {
+	provider := zhipuai.NewJWTCredentials(zhipuai.StaticKey(testJWTKey))
+	token, err := provider.Token(context.Background())
+	checks.NoError(t, err, "Token error")
+
+	claims := &zhipuai.CustomClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return []byte("my-key-secret"), nil
+	})
+	// ExpiresAt is expressed in milliseconds as ZhipuAI expects, which jwt-go
+	// reads as a far-future date, so validation only fails on a bad signature.
+	checks.NoError(t, err, "token should be signed with the key secret")
+	if !parsed.Valid || claims.ApiKey != "my-key-id" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	cached, err := provider.Token(context.Background())
+	checks.NoError(t, err, "Token error")
+	if cached != token {
+		t.Errorf("expected cached token to be reused")
+	}
+}
+
+func TestJWTCredentialsWithoutCacheDuration(t *testing.T) {
+	provider := &zhipuai.JWTCredentials{
+		Key:       zhipuai.StaticKey(testJWTKey),
+		TTL:       time.Minute,
+		ClockSkew: time.Minute,
+	}
+	_, err := provider.Token(context.Background())
+	checks.NoError(t, err, "Token error")
+}
+
+func TestJWTCredentialsDefaultClockSkew(t *testing.T) {
+	// The default clock skew of 30 seconds leaves nothing to cache of a 20 seconds token.
+	provider := &zhipuai.JWTCredentials{
+		Key: zhipuai.StaticKey(testJWTKey),
+		TTL: 20 * time.Second,
+	}
+	first, err := provider.Token(context.Background())
+	checks.NoError(t, err, "Token error")
+	time.Sleep(2 * time.Millisecond)
+	second, err := provider.Token(context.Background())
+	checks.NoError(t, err, "Token error")
+	if first == second {
+		t.Errorf("expected a token about to expire not to be cached")
+	}
+}
+
+func TestJWTCredentialsInvalidKey(t *testing.T) {
+	provider := zhipuai.NewJWTCredentials(zhipuai.StaticKey("no-secret"))
+	_, err := provider.Token(context.Background())
+	checks.ErrorIs(t, err, zhipuai.ErrInvalidAPIKey, "key without secret should be rejected")
+}
+
+func TestCredentialErrorsArePropagated(t *testing.T) {
+	errProvider := errors.New("vault unavailable")
+	config := zhipuai.DefaultConfig("")
+	config.BaseURL = "http://localhost/v1"
+	config.CredentialProvider = zhipuai.CredentialFunc(func(context.Context) (string, error) {
+		return "", errProvider
+	})
+	client := zhipuai.NewClientWithConfig(config)
+
+	_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+	checks.ErrorIs(t, err, errProvider, "credential error should be returned by the API call")
+}
+
+func TestCustomCredentialProvider(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.CredentialProvider = zhipuai.StaticCredentials(test.GetTestToken())
+	})
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", handleChatCompletionEndpoint)
+
+	_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+}
+
+func TestEnvKey(t *testing.T) {
+	const envName = "ZHIPUAI_TEST_API_KEY"
+	source := zhipuai.EnvKey(envName)
+
+	t.Setenv(envName, "")
+	_, err := source(context.Background())
+	checks.ErrorIs(t, err, zhipuai.ErrMissingAPIKey, "unset variable should be reported")
+
+	t.Setenv(envName, testJWTKey)
+	key, err := source(context.Background())
+	checks.NoError(t, err, "EnvKey error")
+	if key != testJWTKey {
+		t.Errorf("expected %q, got %q", testJWTKey, key)
+	}
+}
+
+func TestFileKeyRotation(t *testing.T) {
+	dir, cleanup := test.CreateTestDirectory(t)
+	defer cleanup()
+	path := filepath.Join(dir, "api-key")
+	source := zhipuai.FileKey(path)
+
+	_, err := source(context.Background())
+	checks.HasError(t, err, "missing file should be reported")
+
+	checks.NoError(t, os.WriteFile(path, []byte("first.secret\n"), 0o600))
+	key, err := source(context.Background())
+	checks.NoError(t, err, "FileKey error")
+	if key != "first.secret" {
+		t.Errorf("expected first.secret, got %q", key)
+	}
+
+	checks.NoError(t, os.WriteFile(path, []byte("second.secret"), 0o600))
+	later := time.Now().Add(time.Minute)
+	checks.NoError(t, os.Chtimes(path, later, later))
+	key, err = source(context.Background())
+	checks.NoError(t, err, "FileKey error")
+	if key != "second.secret" {
+		t.Errorf("expected rotated key second.secret, got %q", key)
+	}
+}
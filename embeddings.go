@@ -9,7 +9,12 @@ import (
 	"net/http"
 )
 
-var ErrVectorLengthMismatch = errors.New("vector length mismatch")
+const embeddingsSuffix = "/embeddings"
+
+var (
+	ErrVectorLengthMismatch  = errors.New("vector length mismatch")
+	ErrEmbeddingInvalidModel = errors.New("this model is not supported with this method, please use an embedding model") //nolint:lll
+)
 
 // EmbeddingModel enumerates the models which can be used
 // to generate Embedding vectors.
@@ -37,6 +42,9 @@ const (
 	AdaEmbeddingV2  EmbeddingModel = "text-embedding-ada-002"
 	SmallEmbedding3 EmbeddingModel = "text-embedding-3-small"
 	LargeEmbedding3 EmbeddingModel = "text-embedding-3-large"
+
+	Embedding2 EmbeddingModel = "embedding-2"
+	Embedding3 EmbeddingModel = "embedding-3"
 )
 
 // Embedding is a special format of data representation that can be easily utilized by machine
@@ -241,7 +249,12 @@ func (c *Client) CreateEmbeddings(
 	conv EmbeddingRequestConverter,
 ) (res EmbeddingResponse, err error) {
 	baseReq := conv.Convert()
-	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/embeddings", string(baseReq.Model)), withBody(baseReq))
+	if !checkEndpointSupportsModel(embeddingsSuffix, string(baseReq.Model)) {
+		err = ErrEmbeddingInvalidModel
+		return
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(embeddingsSuffix, string(baseReq.Model)), withBody(baseReq))
 	if err != nil {
 		return
 	}
@@ -15,6 +15,8 @@ type APIError struct {
 	Type           string      `json:"type"`
 	HTTPStatusCode int         `json:"-"`
 	InnerError     *InnerError `json:"innererror,omitempty"`
+	// RequestID is the id of the failed request, when returned by the server.
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // InnerError Azure Content filtering. Only valid for Azure zhipuai Service.
@@ -30,7 +32,15 @@ type RequestError struct {
 }
 
 type ErrorResponse struct {
-	Error *APIError `json:"error,omitempty"`
+	Error     *APIError `json:"error,omitempty"`
+	RequestID string    `json:"request_id,omitempty"`
+}
+
+// setRequestID copies the request id returned next to the error into it.
+func (r *ErrorResponse) setRequestID() {
+	if r != nil && r.Error != nil && r.Error.RequestID == "" {
+		r.Error.RequestID = r.RequestID
+	}
 }
 
 func (e *APIError) Error() string {
@@ -77,6 +87,13 @@ func (e *APIError) UnmarshalJSON(data []byte) (err error) {
 	}
 
 	// optional fields
+	if _, ok := rawMap["request_id"]; ok {
+		err = json.Unmarshal(rawMap["request_id"], &e.RequestID)
+		if err != nil {
+			return
+		}
+	}
+
 	if _, ok := rawMap["param"]; ok {
 		err = json.Unmarshal(rawMap["param"], &e.Param)
 		if err != nil {
@@ -3,7 +3,6 @@ package zhipuai_test
 import (
 	"bufio"
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -193,10 +192,9 @@ func ExampleClient_CreateImage() {
 	respURL, err := client.CreateImage(
 		context.Background(),
 		zhipuai.ImageRequest{
-			Prompt:         "Parrot on a skateboard performs a trick, cartoon style, natural light, high detail",
-			Size:           zhipuai.CreateImageSize256x256,
-			ResponseFormat: zhipuai.CreateImageResponseFormatURL,
-			N:              1,
+			Prompt: "Parrot on a skateboard performs a trick, cartoon style, natural light, high detail",
+			Model:  zhipuai.CreateImageModelCogView3Plus,
+			Size:   zhipuai.CreateImageSize1024x1024,
 		},
 	)
 	if err != nil {
@@ -206,16 +204,15 @@ func ExampleClient_CreateImage() {
 	fmt.Println(respURL.Data[0].URL)
 }
 
-func ExampleClient_CreateImage_base64() {
+func ExampleClient_DownloadImageToFile() {
 	client := zhipuai.NewClient(os.Getenv("zhipuai_API_KEY"))
 
 	resp, err := client.CreateImage(
 		context.Background(),
 		zhipuai.ImageRequest{
-			Prompt:         "Portrait of a humanoid parrot in a classic costume, high detail, realistic light, unreal engine",
-			Size:           zhipuai.CreateImageSize512x512,
-			ResponseFormat: zhipuai.CreateImageResponseFormatB64JSON,
-			N:              1,
+			Prompt: "Portrait of a humanoid parrot in a classic costume, high detail, realistic light, unreal engine",
+			Model:  zhipuai.CreateImageModelCogView3Plus,
+			Size:   zhipuai.CreateImageSize768x1344,
 		},
 	)
 	if err != nil {
@@ -223,26 +220,13 @@ func ExampleClient_CreateImage_base64() {
 		return
 	}
 
-	b, err := base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
+	contentType, err := client.DownloadImageToFile(context.Background(), resp.Data[0].URL, "example.png")
 	if err != nil {
-		fmt.Printf("Base64 decode error: %v\n", err)
+		fmt.Printf("Image download error: %v\n", err)
 		return
 	}
 
-	f, err := os.Create("example.png")
-	if err != nil {
-		fmt.Printf("File creation error: %v\n", err)
-		return
-	}
-	defer f.Close()
-
-	_, err = f.Write(b)
-	if err != nil {
-		fmt.Printf("File write error: %v\n", err)
-		return
-	}
-
-	fmt.Println("The image was saved as example.png")
+	fmt.Printf("The %s image was saved as example.png\n", contentType)
 }
 
 func ExampleClientConfig_clientWithProxy() {
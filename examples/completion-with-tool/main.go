@@ -33,9 +33,16 @@ func main() {
 		Description: "Get the current weather in a given location",
 		Parameters:  params,
 	}
-	t := zhipuai.Tool{
-		Type:     zhipuai.ToolTypeFunction,
-		Function: &f,
+	runner := zhipuai.NewToolRunner(client)
+	err := runner.Register(f, func(_ context.Context, arguments string) (string, error) {
+		// simulate calling the function
+		fmt.Printf("zhipuai called us back wanting to invoke our function '%v' with params '%v'\n",
+			f.Name, arguments)
+		return "Sunny and 80 degrees.", nil
+	})
+	if err != nil {
+		fmt.Printf("Register error: %v\n", err)
+		return
 	}
 
 	// simulate user asking a question that requires the function
@@ -44,11 +51,10 @@ func main() {
 	}
 	fmt.Printf("Asking zhipuai '%v' and providing it a '%v()' function...\n",
 		dialogue[0].Content, f.Name)
-	resp, err := client.CreateChatCompletion(ctx,
+	resp, err := runner.RunWithTools(ctx,
 		zhipuai.ChatCompletionRequest{
-			Model:    zhipuai.GPT4TurboPreview,
+			Model:    zhipuai.GLM4,
 			Messages: dialogue,
-			Tools:    []zhipuai.Tool{t},
 		},
 	)
 	if err != nil || len(resp.Choices) != 1 {
@@ -56,39 +62,8 @@ func main() {
 			len(resp.Choices))
 		return
 	}
-	msg := resp.Choices[0].Message
-	if len(msg.ToolCalls) != 1 {
-		fmt.Printf("Completion error: len(toolcalls): %v\n", len(msg.ToolCalls))
-		return
-	}
-
-	// simulate calling the function & responding to zhipuai
-	dialogue = append(dialogue, msg)
-	fmt.Printf("zhipuai called us back wanting to invoke our function '%v' with params '%v'\n",
-		msg.ToolCalls[0].Function.Name, msg.ToolCalls[0].Function.Arguments)
-	dialogue = append(dialogue, zhipuai.ChatCompletionMessage{
-		Role:       zhipuai.ChatMessageRoleTool,
-		Content:    "Sunny and 80 degrees.",
-		Name:       msg.ToolCalls[0].Function.Name,
-		ToolCallID: msg.ToolCalls[0].ID,
-	})
-	fmt.Printf("Sending zhipuai our '%v()' function's response and requesting the reply to the original question...\n",
-		f.Name)
-	resp, err = client.CreateChatCompletion(ctx,
-		zhipuai.ChatCompletionRequest{
-			Model:    zhipuai.GPT4TurboPreview,
-			Messages: dialogue,
-			Tools:    []zhipuai.Tool{t},
-		},
-	)
-	if err != nil || len(resp.Choices) != 1 {
-		fmt.Printf("2nd completion error: err:%v len(choices):%v\n", err,
-			len(resp.Choices))
-		return
-	}
 
 	// display zhipuai's response to the original question utilizing our function
-	msg = resp.Choices[0].Message
-	fmt.Printf("zhipuai answered the original request with: %v\n",
-		msg.Content)
+	fmt.Printf("zhipuai answered the original request after %v completions with: %v\n",
+		resp.Iterations, resp.Choices[0].Message.Content)
 }
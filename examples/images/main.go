@@ -14,10 +14,9 @@ func main() {
 	respUrl, err := client.CreateImage(
 		context.Background(),
 		zhipuai.ImageRequest{
-			Prompt:         "Parrot on a skateboard performs a trick, cartoon style, natural light, high detail",
-			Size:           zhipuai.CreateImageSize256x256,
-			ResponseFormat: zhipuai.CreateImageResponseFormatURL,
-			N:              1,
+			Prompt: "Parrot on a skateboard performs a trick, cartoon style, natural light, high detail",
+			Model:  zhipuai.CreateImageModelCogView3Plus,
+			Size:   zhipuai.CreateImageSize1024x1024,
 		},
 	)
 	if err != nil {
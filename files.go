@@ -23,6 +23,8 @@ const (
 	PurposeFineTuneResults  PurposeType = "fine-tune-results"
 	PurposeAssistants       PurposeType = "assistants"
 	PurposeAssistantsOutput PurposeType = "assistants_output"
+	PurposeRetrieval        PurposeType = "retrieval"
+	PurposeBatch            PurposeType = "batch"
 )
 
 // FileBytesRequest represents a file upload request.
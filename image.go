@@ -1,11 +1,18 @@
 package zhipuai
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Image sizes defined by the zhipuai API.
@@ -16,6 +23,13 @@ const (
 	// dall-e-3 supported only.
 	CreateImageSize1792x1024 = "1792x1024"
 	CreateImageSize1024x1792 = "1024x1792"
+	// cogview-3-plus supported only.
+	CreateImageSize768x1344 = "768x1344"
+	CreateImageSize864x1152 = "864x1152"
+	CreateImageSize1344x768 = "1344x768"
+	CreateImageSize1152x864 = "1152x864"
+	CreateImageSize1440x720 = "1440x720"
+	CreateImageSize720x1440 = "720x1440"
 )
 
 const (
@@ -24,8 +38,28 @@ const (
 )
 
 const (
+	// Deprecated: ZhipuAI does not serve DALL-E models, use CreateImageModelCogView3Plus instead.
 	CreateImageModelDallE2 = "dall-e-2"
-	CreateImageModelDallE3 = "dall-e-3"
+	// Deprecated: ZhipuAI does not serve DALL-E models, use CreateImageModelCogView3Plus instead.
+	CreateImageModelDallE3       = "dall-e-3"
+	CreateImageModelCogView3     = "cogview-3"
+	CreateImageModelCogView3Plus = "cogview-3-plus"
+)
+
+const imageGenerationsSuffix = "/images/generations"
+
+// MaxImageDownloadSize is the largest image accepted by DownloadImage.
+const MaxImageDownloadSize = 20 << 20
+
+var (
+	ErrImageInvalidModel          = errors.New("this model is not supported with this method, please use an image generation model") //nolint:lll
+	ErrImagePromptRequired        = errors.New("image generation requires a prompt")
+	ErrImageSizeNotSupported      = errors.New("this model does not support the image size")
+	ErrImageEditNotSupported      = errors.New("ZhipuAI does not offer image edits, use CreateImage instead")
+	ErrImageVariationNotSupported = errors.New("ZhipuAI does not offer image variations, use CreateImage instead")
+	ErrImageDownloadFailed        = errors.New("image download failed")
+	ErrImageDownloadNotImage      = errors.New("the downloaded content is not an image")
+	ErrImageDownloadTooLarge      = errors.New("the downloaded image exceeds the maximum size")
 )
 
 const (
@@ -33,6 +67,7 @@ const (
 	CreateImageQualityStandard = "standard"
 )
 
+// Styles are only accepted by DALL-E 3.
 const (
 	CreateImageStyleVivid   = "vivid"
 	CreateImageStyleNatural = "natural"
@@ -48,6 +83,8 @@ type ImageRequest struct {
 	Style          string `json:"style,omitempty"`
 	ResponseFormat string `json:"response_format,omitempty"`
 	User           string `json:"user,omitempty"`
+	// UserID identifies the end user, to help the platform detect abuse.
+	UserID string `json:"user_id,omitempty"`
 }
 
 // ImageResponse represents a response structure for image API.
@@ -65,9 +102,17 @@ type ImageResponseDataInner struct {
 	RevisedPrompt string `json:"revised_prompt,omitempty"`
 }
 
-// CreateImage - API call to create an image. This is the main endpoint of the DALL-E API.
+// CreateImage - API call to create an image with a CogView model.
 func (c *Client) CreateImage(ctx context.Context, request ImageRequest) (response ImageResponse, err error) {
-	urlSuffix := "/images/generations"
+	urlSuffix := imageGenerationsSuffix
+	if !checkEndpointSupportsModel(urlSuffix, request.Model) {
+		err = ErrImageInvalidModel
+		return
+	}
+	if err = validateImageRequest(request); err != nil {
+		return
+	}
+
 	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(urlSuffix, request.Model), withBody(request))
 	if err != nil {
 		return
@@ -89,7 +134,14 @@ type ImageEditRequest struct {
 }
 
 // CreateEditImage - API call to create an image. This is the main endpoint of the DALL-E API.
+// The ZhipuAI API has no such endpoint: ErrImageEditNotSupported is returned unless
+// BaseURL points to a compatible server.
 func (c *Client) CreateEditImage(ctx context.Context, request ImageEditRequest) (response ImageResponse, err error) {
+	if c.targetsZhipuaiAPI() {
+		err = ErrImageEditNotSupported
+		return
+	}
+
 	body := &bytes.Buffer{}
 	builder := c.createFormBuilder(body)
 
@@ -153,7 +205,14 @@ type ImageVariRequest struct {
 
 // CreateVariImage - API call to create an image variation. This is the main endpoint of the DALL-E API.
 // Use abbreviations(vari for variation) because ci-lint has a single-line length limit ...
+// The ZhipuAI API has no such endpoint: ErrImageVariationNotSupported is returned unless
+// BaseURL points to a compatible server.
 func (c *Client) CreateVariImage(ctx context.Context, request ImageVariRequest) (response ImageResponse, err error) {
+	if c.targetsZhipuaiAPI() {
+		err = ErrImageVariationNotSupported
+		return
+	}
+
 	body := &bytes.Buffer{}
 	builder := c.createFormBuilder(body)
 
@@ -192,3 +251,123 @@ func (c *Client) CreateVariImage(ctx context.Context, request ImageVariRequest)
 	err = c.sendRequest(req, &response)
 	return
 }
+
+// targetsZhipuaiAPI reports whether BaseURL points to the ZhipuAI API, comparing
+// the host and path so that equivalent spellings of the URL match.
+func (c *Client) targetsZhipuaiAPI() bool {
+	base, err := url.Parse(c.config.BaseURL)
+	if err != nil {
+		return false
+	}
+	api, _ := url.Parse(zhipuaiAPIURLv1)
+	if port := base.Port(); port != "" && port != "443" {
+		return false
+	}
+	return strings.EqualFold(base.Hostname(), api.Hostname()) &&
+		strings.TrimRight(base.Path, "/") == api.Path
+}
+
+// validateImageRequest checks an image request against the capabilities of its
+// model. Requests for unknown models are not validated.
+func validateImageRequest(request ImageRequest) error {
+	capabilities, ok := LookupModel(request.Model)
+	if !ok {
+		return nil
+	}
+	if request.Prompt == "" {
+		return ErrImagePromptRequired
+	}
+	if request.Size != "" && len(capabilities.ImageSizes) > 0 && !containsString(capabilities.ImageSizes, request.Size) {
+		return fmt.Errorf("%w: %s", ErrImageSizeNotSupported, request.Size)
+	}
+
+	unsupported := []struct {
+		name string
+		set  bool
+	}{
+		{"n", request.N > 1},
+		{"quality", request.Quality != ""},
+		{"style", request.Style != ""},
+		{"response_format", request.ResponseFormat != "" && request.ResponseFormat != CreateImageResponseFormatURL},
+	}
+	for _, param := range unsupported {
+		if param.set {
+			return fmt.Errorf("%w: %s", ErrModelParamNotSupported, param.name)
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// DownloadImage writes the image found at imageURL, such as the URL of a generated
+// image, to w and returns its content type. The content type sent by the server
+// is used when it is an image type, otherwise it is detected from the content.
+// The download is not authenticated since image URLs are pre-signed. Images
+// larger than MaxImageDownloadSize fail with ErrImageDownloadTooLarge once
+// MaxImageDownloadSize bytes have been written.
+func (c *Client) DownloadImage(ctx context.Context, imageURL string, w io.Writer) (contentType string, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return
+	}
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		err = &RequestError{HTTPStatusCode: resp.StatusCode, Err: ErrImageDownloadFailed}
+		return
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	// http.DetectContentType considers at most the first 512 bytes.
+	head, err := reader.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return
+	}
+	contentType, _, _ = mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = http.DetectContentType(head)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		err = fmt.Errorf("%w: %s", ErrImageDownloadNotImage, contentType)
+		return
+	}
+
+	n, err := io.Copy(w, io.LimitReader(reader, MaxImageDownloadSize))
+	if err != nil || n < MaxImageDownloadSize {
+		return
+	}
+	if _, peekErr := reader.Peek(1); peekErr == nil {
+		err = ErrImageDownloadTooLarge
+	}
+	return
+}
+
+// DownloadImageToFile is like DownloadImage, writing the image to the file at path.
+// The file is removed if the download fails.
+func (c *Client) DownloadImageToFile(ctx context.Context, imageURL, path string) (contentType string, err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return
+	}
+
+	contentType, err = c.DownloadImage(ctx, imageURL, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return
+}
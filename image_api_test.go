@@ -1,12 +1,16 @@
 package zhipuai_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -31,6 +35,136 @@ func TestImages(t *testing.T) {
 	checks.NoError(t, err, "CreateImage error")
 }
 
+func TestImagesCogView(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/images/generations", handleImageEndpoint)
+	_, err := client.CreateImage(context.Background(), zhipuai.ImageRequest{
+		Prompt: "Lorem ipsum",
+		Model:  zhipuai.CreateImageModelCogView3Plus,
+		N:      1,
+		Size:   zhipuai.CreateImageSize1440x720,
+		UserID: "user-123",
+	})
+	checks.NoError(t, err, "CreateImage error")
+}
+
+func TestImageCogViewValidation(t *testing.T) {
+	client := newOfflineClient()
+	valid := zhipuai.ImageRequest{
+		Prompt: "a cat",
+		Model:  zhipuai.CreateImageModelCogView3Plus,
+		Size:   zhipuai.CreateImageSize768x1344,
+	}
+	tests := []struct {
+		name   string
+		modify func(*zhipuai.ImageRequest)
+		want   error
+	}{
+		{"empty prompt", func(r *zhipuai.ImageRequest) { r.Prompt = "" }, zhipuai.ErrImagePromptRequired},
+		{"dall-e size", func(r *zhipuai.ImageRequest) {
+			r.Size = zhipuai.CreateImageSize256x256
+		}, zhipuai.ErrImageSizeNotSupported},
+		{"cogview-3 size", func(r *zhipuai.ImageRequest) {
+			r.Model = zhipuai.CreateImageModelCogView3
+		}, zhipuai.ErrImageSizeNotSupported},
+		{"n", func(r *zhipuai.ImageRequest) { r.N = 2 }, zhipuai.ErrModelParamNotSupported},
+		{"style", func(r *zhipuai.ImageRequest) {
+			r.Style = zhipuai.CreateImageStyleVivid
+		}, zhipuai.ErrModelParamNotSupported},
+		{"b64_json", func(r *zhipuai.ImageRequest) {
+			r.ResponseFormat = zhipuai.CreateImageResponseFormatB64JSON
+		}, zhipuai.ErrModelParamNotSupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			_, err := client.CreateImage(context.Background(), req)
+			checks.ErrorIs(t, err, tt.want, "invalid image request should be rejected")
+		})
+	}
+}
+
+func TestImageEditAndVariationNotSupported(t *testing.T) {
+	for _, baseURL := range []string{
+		"https://open.bigmodel.cn/api/paas/v4",
+		"https://open.bigmodel.cn/api/paas/v4/",
+		"https://OPEN.bigmodel.cn:443/api/paas/v4",
+	} {
+		config := zhipuai.DefaultConfig("whatever")
+		config.BaseURL = baseURL
+		client := zhipuai.NewClientWithConfig(config)
+		_, err := client.CreateEditImage(context.Background(), zhipuai.ImageEditRequest{Prompt: "a cat"})
+		checks.ErrorIs(t, err, zhipuai.ErrImageEditNotSupported, "image edits should be rejected for "+baseURL)
+		_, err = client.CreateVariImage(context.Background(), zhipuai.ImageVariRequest{})
+		checks.ErrorIs(t, err, zhipuai.ErrImageVariationNotSupported, "image variations should be rejected for "+baseURL)
+	}
+}
+
+type countingWriter int
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+func TestDownloadImage(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("image downloads should not be authenticated")
+		}
+		switch r.URL.Path {
+		case "/image.png":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(png) //nolint:errcheck
+		case "/large.png":
+			w.Write(png)                                        //nolint:errcheck
+			w.Write(make([]byte, zhipuai.MaxImageDownloadSize)) //nolint:errcheck
+		case "/page.html":
+			fmt.Fprint(w, "<html><body>expired</body></html>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	client := newOfflineClient()
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	contentType, err := client.DownloadImage(ctx, ts.URL+"/image.png", &buf)
+	checks.NoError(t, err, "DownloadImage error")
+	if contentType != "image/png" || !bytes.Equal(buf.Bytes(), png) {
+		t.Errorf("unexpected download: %s %q", contentType, buf.Bytes())
+	}
+
+	var written countingWriter
+	_, err = client.DownloadImage(ctx, ts.URL+"/large.png", &written)
+	checks.ErrorIs(t, err, zhipuai.ErrImageDownloadTooLarge, "large images should be rejected")
+	if written != zhipuai.MaxImageDownloadSize {
+		t.Errorf("expected %d bytes to be written, got %d", zhipuai.MaxImageDownloadSize, written)
+	}
+
+	_, err = client.DownloadImage(ctx, ts.URL+"/page.html", io.Discard)
+	checks.ErrorIs(t, err, zhipuai.ErrImageDownloadNotImage, "HTML pages should be rejected")
+
+	path := filepath.Join(t.TempDir(), "image.png")
+	_, err = client.DownloadImageToFile(ctx, ts.URL+"/missing.png", path)
+	checks.ErrorIs(t, err, zhipuai.ErrImageDownloadFailed, "missing images should fail")
+	if _, statErr := os.Stat(path); !errors.Is(statErr, os.ErrNotExist) {
+		t.Errorf("expected the file of a failed download to be removed, got %v", statErr)
+	}
+
+	contentType, err = client.DownloadImageToFile(ctx, ts.URL+"/image.png", path)
+	checks.NoError(t, err, "DownloadImageToFile error")
+	data, err := os.ReadFile(path)
+	checks.NoError(t, err, "ReadFile error")
+	if contentType != "image/png" || !bytes.Equal(data, png) {
+		t.Errorf("unexpected file: %s %q", contentType, data)
+	}
+}
+
 // handleImageEndpoint Handles the images endpoint by the test server.
 func handleImageEndpoint(w http.ResponseWriter, r *http.Request) {
 	var err error
@@ -0,0 +1,161 @@
+package zhipuai
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"time"
+)
+
+// SSEEvent is a message of a server-sent events stream.
+type SSEEvent struct {
+	// Event is the event name, empty for the default "message" event.
+	Event string
+	// ID is the last event id seen on the stream, including this message.
+	ID string
+	// Data holds the data lines of the message joined with "\n".
+	Data []byte
+	// Retry is the reconnection time requested by the server, 0 if not set.
+	Retry time.Duration
+	// Unknown holds the lines that are not valid event stream fields, such as
+	// a JSON error body sent without the "data:" prefix.
+	Unknown [][]byte
+
+	hasData bool
+	hasID   bool
+}
+
+// Empty reports whether the message carries neither data nor any field.
+func (e *SSEEvent) Empty() bool {
+	return !e.hasData && e.Event == "" && !e.hasID && e.Retry == 0 && len(e.Unknown) == 0
+}
+
+// HasData reports whether the message had at least one data line with data.
+func (e *SSEEvent) HasData() bool {
+	return e.hasData
+}
+
+func (e SSEEvent) withData(data []byte) SSEEvent {
+	e.Data = data
+	if len(data) == 0 {
+		e.hasData = false
+	}
+	return e
+}
+
+var (
+	sseFieldData  = []byte("data")
+	sseFieldEvent = []byte("event")
+	sseFieldID    = []byte("id")
+	sseFieldRetry = []byte("retry")
+)
+
+// SSEDecoder reads messages from a server-sent events stream as specified by
+// https://html.spec.whatwg.org/multipage/server-sent-events.html.
+type SSEDecoder struct {
+	reader      *bufio.Reader
+	lastEventID string
+	// afterCR is set when the last line ended with "\r", so that the "\n" of a
+	// "\r\n" terminator is skipped without waiting for more input.
+	afterCR bool
+}
+
+func NewSSEDecoder(reader *bufio.Reader) *SSEDecoder {
+	return &SSEDecoder{reader: reader}
+}
+
+// LastEventID returns the last event id received on the stream.
+func (d *SSEDecoder) LastEventID() string {
+	return d.lastEventID
+}
+
+// Next reads the next message, which ends at a blank line. Blank lines with
+// no preceding field are returned as empty messages, while messages made only
+// of comments, such as heartbeats, are skipped. As the specification does not
+// dispatch messages with empty data, these are returned without data.
+//
+// Unlike the specification, a message with data that is cut by the end of the
+// stream is still returned, along with io.EOF on the following call.
+func (d *SSEDecoder) Next() (SSEEvent, error) {
+	event := SSEEvent{ID: d.lastEventID}
+	hasComment := false
+	var data bytes.Buffer
+
+	for {
+		line, err := d.readLine()
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err == io.EOF && (event.hasData || len(event.Unknown) > 0) {
+				return event.withData(data.Bytes()), nil
+			}
+			return SSEEvent{}, err
+		}
+
+		if len(line) == 0 {
+			if hasComment && event.Empty() {
+				hasComment = false
+				continue
+			}
+			return event.withData(data.Bytes()), nil
+		}
+
+		if line[0] == ':' {
+			hasComment = true
+			continue
+		}
+
+		field, value := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], line[i+1:]
+			value = bytes.TrimPrefix(value, []byte(" "))
+		}
+
+		switch {
+		case bytes.Equal(field, sseFieldData):
+			if event.hasData {
+				data.WriteByte('\n')
+			}
+			data.Write(value)
+			event.hasData = true
+		case bytes.Equal(field, sseFieldEvent):
+			event.Event = string(value)
+		case bytes.Equal(field, sseFieldID):
+			if bytes.IndexByte(value, 0) < 0 {
+				d.lastEventID = string(value)
+				event.ID = d.lastEventID
+				event.hasID = true
+			}
+		case bytes.Equal(field, sseFieldRetry):
+			if ms, parseErr := strconv.ParseUint(string(value), 10, 64); parseErr == nil {
+				event.Retry = time.Duration(ms) * time.Millisecond
+			}
+		default:
+			event.Unknown = append(event.Unknown, bytes.TrimSpace(line))
+		}
+	}
+}
+
+// readLine reads a line ended by "\r\n", "\n" or "\r", and returns it without
+// its terminator. A line cut by the end of the stream is returned with io.EOF.
+func (d *SSEDecoder) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		b, err := d.reader.ReadByte()
+		if err != nil {
+			return line, err
+		}
+		afterCR := d.afterCR
+		d.afterCR = false
+		switch {
+		case b == '\n' && afterCR:
+			// second half of a "\r\n" terminator
+		case b == '\n':
+			return line, nil
+		case b == '\r':
+			d.afterCR = true
+			return line, nil
+		default:
+			line = append(line, b)
+		}
+	}
+}
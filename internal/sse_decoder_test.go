@@ -0,0 +1,93 @@
+package zhipuai_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	utils "github.com/bbang94/go-zhipuai/internal"
+)
+
+func readSSEEvents(t *testing.T, input string) []utils.SSEEvent {
+	t.Helper()
+	decoder := utils.NewSSEDecoder(bufio.NewReader(strings.NewReader(input)))
+	var events []utils.SSEEvent
+	for {
+		event, err := decoder.Next()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		events = append(events, event)
+	}
+}
+
+func TestSSEDecoderFields(t *testing.T) {
+	input := "event: add\r\nid: 1\r\nretry: 1500\r\ndata:first\r\ndata: second\r\n\r\n" +
+		": heartbeat\n\n" +
+		"data: third\n\n"
+	events := readSSEEvents(t, input)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(events), events)
+	}
+
+	first := events[0]
+	if first.Event != "add" || first.ID != "1" || first.Retry != 1500*time.Millisecond {
+		t.Errorf("unexpected fields: %+v", first)
+	}
+	if string(first.Data) != "first\nsecond" {
+		t.Errorf("expected multi-line data to be joined, got %q", first.Data)
+	}
+
+	second := events[1]
+	if second.Event != "" || second.ID != "1" || string(second.Data) != "third" {
+		t.Errorf("expected the last event id to carry over, got %+v", second)
+	}
+}
+
+func TestSSEDecoderLineTerminators(t *testing.T) {
+	events := readSSEEvents(t, "data: cr\r\rdata: crlf\r\n\r\ndata: lf\n\ndata: a\rdata: b\r\n\n")
+	var data []string
+	for _, event := range events {
+		data = append(data, string(event.Data))
+	}
+	if strings.Join(data, "|") != "cr|crlf|lf|a\nb" {
+		t.Errorf("unexpected messages: %q", data)
+	}
+}
+
+func TestSSEDecoderEmptyMessages(t *testing.T) {
+	events := readSSEEvents(t, "\n\n:comment\n\ndata:\n\ndata\n\nevent: ping\ndata:\n\ndata:\ndata:\n\n")
+	if len(events) != 6 {
+		t.Fatalf("expected 6 events, got %d: %+v", len(events), events)
+	}
+	if !events[0].Empty() || !events[1].Empty() {
+		t.Errorf("expected blank lines to be empty messages")
+	}
+	if !events[2].Empty() || !events[3].Empty() {
+		t.Errorf("expected empty data lines to be empty messages, got %+v", events[2:4])
+	}
+	if events[4].HasData() || events[4].Event != "ping" {
+		t.Errorf("expected a named message with empty data to have no data, got %+v", events[4])
+	}
+	if !events[5].HasData() || string(events[5].Data) != "\n" {
+		t.Errorf("expected two empty data lines to be a line feed, got %+v", events[5])
+	}
+}
+
+func TestSSEDecoderUnknownLinesAndEOF(t *testing.T) {
+	events := readSSEEvents(t, "{\n\"error\": {}\n}\n")
+	if len(events) != 1 || len(events[0].Unknown) != 3 || events[0].HasData() {
+		t.Fatalf("expected the unknown lines to be returned at EOF, got %+v", events)
+	}
+
+	events = readSSEEvents(t, "data: [DONE]")
+	if len(events) != 1 || string(events[0].Data) != "[DONE]" {
+		t.Errorf("expected a message cut by EOF to be returned, got %+v", events)
+	}
+}
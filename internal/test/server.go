@@ -25,13 +25,13 @@ func NewTestServer() *ServerTest {
 
 func (ts *ServerTest) RegisterHandler(path string, handler handler) {
 	// to make the registered paths friendlier to a regex match in the route handler
-	// in zhipuaiTestServer
+	// in ZhipuaiTestServer
 	path = strings.ReplaceAll(path, "*", ".*")
 	ts.handlers[path] = handler
 }
 
-// zhipuaiTestServer Creates a mocked zhipuai server which can pretend to handle requests during testing.
-func (ts *ServerTest) zhipuaiTestServer() *httptest.Server {
+// ZhipuaiTestServer Creates a mocked zhipuai server which can pretend to handle requests during testing.
+func (ts *ServerTest) ZhipuaiTestServer() *httptest.Server {
 	return httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("received a %s request at path %q\n", r.Method, r.URL.Path)
 
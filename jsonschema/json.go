@@ -4,7 +4,16 @@
 // and/or pass in the schema in []byte format.
 package jsonschema
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrMultipleTypes is returned when unmarshaling a schema allowing several non-null types.
+var ErrMultipleTypes = errors.New("only a single type, optionally with null, is supported")
 
 type DataType string
 
@@ -23,27 +32,167 @@ const (
 type Definition struct {
 	// Type specifies the data type of the schema.
 	Type DataType `json:"type,omitempty"`
+	// Nullable allows null in addition to Type; the type is then marshaled as [Type, "null"].
+	Nullable bool `json:"-"`
 	// Description is the description of the schema.
 	Description string `json:"description,omitempty"`
 	// Enum is used to restrict a value to a fixed set of values. It must be an array with at least
-	// one element, where each element is unique. You will probably only use this with strings.
+	// one element, where each element is unique. Elements are marshaled as numbers when Type is
+	// Number or Integer, and as booleans when Type is Boolean.
 	Enum []string `json:"enum,omitempty"`
+	// Const restricts a value to a single constant.
+	Const any `json:"const,omitempty"`
+	// Default is the value assumed when the value is missing. It is not used by Validate.
+	Default any `json:"default,omitempty"`
+	// Format is a semantic format of a string, such as "date-time" or "email". It is not used by Validate.
+	Format string `json:"format,omitempty"`
 	// Properties describes the properties of an object, if the schema type is Object.
 	Properties map[string]Definition `json:"properties"`
 	// Required specifies which properties are required, if the schema type is Object.
 	Required []string `json:"required,omitempty"`
 	// Items specifies which data type an array contains, if the schema type is Array.
 	Items *Definition `json:"items,omitempty"`
+	// AdditionalProperties describes the properties of an object that are not listed in
+	// Properties. It is either a Definition or a bool, false forbidding any other property.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+	// Minimum and Maximum are the inclusive bounds of a number.
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+	// MinLength and MaxLength bound the number of characters of a string.
+	MinLength *int `json:"minLength,omitempty"`
+	MaxLength *int `json:"maxLength,omitempty"`
+	// Pattern is a regular expression that a string must match.
+	Pattern string `json:"pattern,omitempty"`
+	// AnyOf, OneOf and AllOf require a value to match at least one, exactly one or all of
+	// their schemas.
+	AnyOf []Definition `json:"anyOf,omitempty"`
+	OneOf []Definition `json:"oneOf,omitempty"`
+	AllOf []Definition `json:"allOf,omitempty"`
+	// Ref references another schema, either the root schema with "#" or one of its Defs
+	// with "#/$defs/<name>".
+	Ref string `json:"$ref,omitempty"`
+	// Defs holds the schemas referenced by Ref, typically on the root schema.
+	Defs map[string]Definition `json:"$defs,omitempty"`
 }
 
 func (d Definition) MarshalJSON() ([]byte, error) {
 	if d.Properties == nil {
 		d.Properties = make(map[string]Definition)
 	}
+	var typ any
+	switch {
+	case d.Nullable && d.Type != "" && d.Type != Null:
+		typ = []DataType{d.Type, Null}
+	case d.Type != "":
+		typ = d.Type
+	}
 	type Alias Definition
 	return json.Marshal(struct {
 		Alias
+		Type any   `json:"type,omitempty"`
+		Enum []any `json:"enum,omitempty"`
 	}{
 		Alias: (Alias)(d),
+		Type:  typ,
+		Enum:  d.enumValues(),
 	})
 }
+
+func (d *Definition) UnmarshalJSON(data []byte) error {
+	type Alias Definition
+	aux := struct {
+		*Alias
+		Type                 json.RawMessage   `json:"type,omitempty"`
+		Enum                 []json.RawMessage `json:"enum,omitempty"`
+		AdditionalProperties json.RawMessage   `json:"additionalProperties,omitempty"`
+	}{
+		Alias: (*Alias)(d),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	d.Enum = nil
+	for _, member := range aux.Enum {
+		var s string
+		if err := json.Unmarshal(member, &s); err != nil {
+			// Numbers and booleans are kept as their JSON text.
+			s = string(member)
+		}
+		d.Enum = append(d.Enum, s)
+	}
+
+	if len(aux.AdditionalProperties) > 0 {
+		var allowed bool
+		if err := json.Unmarshal(aux.AdditionalProperties, &allowed); err == nil {
+			d.AdditionalProperties = allowed
+		} else {
+			var additional Definition
+			if err = json.Unmarshal(aux.AdditionalProperties, &additional); err != nil {
+				return err
+			}
+			d.AdditionalProperties = additional
+		}
+	}
+
+	if len(aux.Type) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(aux.Type, &d.Type); err == nil {
+		return nil
+	}
+	var types []DataType
+	if err := json.Unmarshal(aux.Type, &types); err != nil {
+		return err
+	}
+	for _, t := range types {
+		switch {
+		case t == Null && len(types) > 1:
+			d.Nullable = true
+		case d.Type == "":
+			d.Type = t
+		default:
+			return fmt.Errorf("%w: %v", ErrMultipleTypes, types)
+		}
+	}
+	return nil
+}
+
+// enumValues returns the elements of Enum as the JSON values they stand for.
+func (d Definition) enumValues() []any {
+	if len(d.Enum) == 0 {
+		return nil
+	}
+	values := make([]any, len(d.Enum))
+	for i, member := range d.Enum {
+		values[i], _ = enumValue(d.Type, member)
+	}
+	return values
+}
+
+// enumValue converts an enum element to a JSON value of type t. It returns the
+// element as a string and false if it does not denote a value of that type.
+func enumValue(t DataType, member string) (any, bool) {
+	switch t {
+	case String, "":
+		return member, true
+	case Number, Integer:
+		if !json.Valid([]byte(member)) {
+			return member, false
+		}
+		if _, err := strconv.ParseFloat(member, 64); err != nil {
+			return member, false
+		}
+		if t == Integer && strings.ContainsAny(member, ".eE") {
+			return member, false
+		}
+		return json.Number(member), true
+	case Boolean:
+		if member != "true" && member != "false" {
+			return member, false
+		}
+		return member == "true", true
+	default:
+		return member, false
+	}
+}
@@ -2,6 +2,7 @@ package jsonschema_test
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect"
 	"testing"
 
@@ -209,3 +210,62 @@ func structToMap(t *testing.T, v any) map[string]any {
 	}
 	return got
 }
+
+func TestDefinition_JSONSchemaKeywords(t *testing.T) {
+	minimum, maxLength := 1.0, 8
+	def := jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"name":  {Type: jsonschema.String, Nullable: true, MaxLength: &maxLength, Pattern: "^[a-z]+$"},
+			"count": {Type: jsonschema.Integer, Minimum: &minimum, Default: 1, Enum: []string{"1", "2"}},
+			"kind":  {Const: "item"},
+			"when":  {Type: jsonschema.String, Format: "date-time"},
+			"value": {AnyOf: []jsonschema.Definition{{Type: jsonschema.String}, {Ref: "#/$defs/money"}}},
+		},
+		AdditionalProperties: false,
+		Defs: map[string]jsonschema.Definition{
+			"money": {Type: jsonschema.Number, Maximum: &minimum},
+		},
+	}
+	want := `{
+		"type": "object",
+		"properties": {
+			"name": {"type": ["string", "null"], "maxLength": 8, "pattern": "^[a-z]+$", "properties": {}},
+			"count": {"type": "integer", "minimum": 1, "default": 1, "enum": [1, 2], "properties": {}},
+			"kind": {"const": "item", "properties": {}},
+			"when": {"type": "string", "format": "date-time", "properties": {}},
+			"value": {
+				"anyOf": [{"type": "string", "properties": {}}, {"$ref": "#/$defs/money", "properties": {}}],
+				"properties": {}
+			}
+		},
+		"additionalProperties": false,
+		"$defs": {"money": {"type": "number", "maximum": 1, "properties": {}}}
+	}`
+	var wantMap map[string]any
+	if err := json.Unmarshal([]byte(want), &wantMap); err != nil {
+		t.Fatalf("Failed to Unmarshal JSON: error = %v", err)
+	}
+	if got := structToMap(t, def); !reflect.DeepEqual(got, wantMap) {
+		t.Errorf("MarshalJSON() got = %v, want %v", got, wantMap)
+	}
+
+	var decoded jsonschema.Definition
+	if err := json.Unmarshal([]byte(want), &decoded); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if got := structToMap(t, decoded); !reflect.DeepEqual(got, wantMap) {
+		t.Errorf("UnmarshalJSON() round trip got = %v, want %v", got, wantMap)
+	}
+	if name := decoded.Properties["name"]; name.Type != jsonschema.String || !name.Nullable {
+		t.Errorf("expected a nullable string, got %+v", name)
+	}
+	if additional, ok := decoded.AdditionalProperties.(bool); !ok || additional {
+		t.Errorf("expected additionalProperties to be false, got %v", decoded.AdditionalProperties)
+	}
+
+	err := json.Unmarshal([]byte(`{"type":["string","integer"]}`), &decoded)
+	if !errors.Is(err, jsonschema.ErrMultipleTypes) {
+		t.Errorf("expected ErrMultipleTypes, got %v", err)
+	}
+}
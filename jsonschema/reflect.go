@@ -0,0 +1,217 @@
+package jsonschema
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupportedType = errors.New("type cannot be described by a JSON schema")
+	ErrInvalidEnum     = errors.New("enum value does not match the field type")
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// GenerateSchemaForType returns the schema of the JSON encoding of v's type,
+// following the rules of encoding/json:
+//   - exported struct fields become properties named after their json tag, and
+//     fields tagged "-" are skipped. Fields of embedded structs are promoted;
+//   - a property is required unless its json tag has the omitempty option;
+//   - the description tag sets the description of a property, and the enum tag
+//     restricts it to a comma-separated list of values, which must be valid
+//     values of the field type;
+//   - pointers are described by the type they point to, time.Time as a
+//     date-time string, and maps as objects whose additional properties have
+//     the map value schema;
+//   - a struct type that contains itself is referenced with $ref "#" when it
+//     is the type of v, and otherwise defined once in the $defs of the root
+//     schema under its type name.
+func GenerateSchemaForType(v any) (*Definition, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("%w: nil", ErrUnsupportedType)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	r := &reflector{
+		root:      t,
+		visiting:  map[reflect.Type]bool{},
+		recursive: map[reflect.Type]bool{},
+	}
+	definition, err := r.reflectSchema(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.defs) > 0 {
+		definition.Defs = r.defs
+	}
+	return &definition, nil
+}
+
+// reflector generates the schemas of the types reachable from a root type.
+type reflector struct {
+	root reflect.Type
+	// visiting holds the struct types being generated, to detect recursion.
+	visiting map[reflect.Type]bool
+	// recursive holds the struct types referenced from their own schema.
+	recursive map[reflect.Type]bool
+	defs      map[string]Definition
+}
+
+func (r *reflector) reflectSchema(t reflect.Type) (Definition, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return Definition{Type: String, Format: "date-time"}, nil
+	case t.Kind() != reflect.String && t.Implements(textMarshalerType):
+		return Definition{Type: String}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Definition{Type: String}, nil
+	case reflect.Bool:
+		return Definition{Type: Boolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Definition{Type: Integer}, nil
+	case reflect.Float32, reflect.Float64:
+		return Definition{Type: Number}, nil
+	case reflect.Interface:
+		return Definition{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes []byte as a base64 string.
+			return Definition{Type: String}, nil
+		}
+		items, err := r.reflectSchema(t.Elem())
+		if err != nil {
+			return Definition{}, err
+		}
+		return Definition{Type: Array, Items: &items}, nil
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			if !t.Key().Implements(textMarshalerType) {
+				return Definition{}, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+			}
+		}
+		values, err := r.reflectSchema(t.Elem())
+		if err != nil {
+			return Definition{}, err
+		}
+		return Definition{Type: Object, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return r.reflectStruct(t)
+	default:
+		return Definition{}, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+}
+
+func (r *reflector) reflectStruct(t reflect.Type) (Definition, error) {
+	if r.visiting[t] {
+		r.recursive[t] = true
+		return Definition{Ref: r.ref(t)}, nil
+	}
+	r.visiting[t] = true
+	defer delete(r.visiting, t)
+
+	definition := Definition{Type: Object, Properties: map[string]Definition{}}
+	if err := r.reflectStructFields(t, &definition); err != nil {
+		return Definition{}, err
+	}
+	if t == r.root || !r.recursive[t] {
+		return definition, nil
+	}
+
+	if r.defs == nil {
+		r.defs = map[string]Definition{}
+	}
+	r.defs[t.Name()] = definition
+	return Definition{Ref: r.ref(t)}, nil
+}
+
+func (r *reflector) ref(t reflect.Type) string {
+	if t == r.root {
+		return "#"
+	}
+	return "#/$defs/" + t.Name()
+}
+
+func (r *reflector) reflectStructFields(t reflect.Type, definition *Definition) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			if r.visiting[fieldType] {
+				continue
+			}
+			r.visiting[fieldType] = true
+			err := r.reflectStructFields(fieldType, definition)
+			delete(r.visiting, fieldType)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property, err := r.reflectSchema(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		property.Description = field.Tag.Get("description")
+		if enum := field.Tag.Get("enum"); enum != "" {
+			property.Enum = strings.Split(enum, ",")
+			for _, member := range property.Enum {
+				if _, ok := enumValue(property.Type, member); !ok {
+					return fmt.Errorf("field %s: %w: %q", field.Name, ErrInvalidEnum, member)
+				}
+			}
+		}
+		definition.Properties[name] = property
+		if !hasOption(options, "omitempty") {
+			definition.Required = append(definition.Required, name)
+		}
+	}
+	return nil
+}
+
+func hasOption(options, option string) bool {
+	for options != "" {
+		var current string
+		current, options, _ = strings.Cut(options, ",")
+		if current == option {
+			return true
+		}
+	}
+	return false
+}
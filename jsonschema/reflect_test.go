@@ -0,0 +1,171 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bbang94/go-zhipuai/jsonschema"
+)
+
+type Audit struct {
+	CreatedAt time.Time `json:"created_at" description:"Creation time"`
+	Author    *string   `json:"author,omitempty"`
+}
+
+type Address struct {
+	City string `json:"city"`
+}
+
+type weatherParams struct {
+	Audit
+	Location string            `json:"location" description:"The city and state"`
+	Unit     string            `json:"unit,omitempty" enum:"celsius,fahrenheit"`
+	Days     int               `json:"days,omitempty" enum:"1,3,7"`
+	Ratio    float64           `json:"ratio"`
+	Exact    bool              `json:"exact"`
+	Home     *Address          `json:"home,omitempty"`
+	Stops    []Address         `json:"stops"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Raw      []byte            `json:"raw,omitempty"`
+	Extra    any               `json:"extra,omitempty"`
+	Ignored  string            `json:"-"`
+	Untagged string
+	private  string
+}
+
+func TestGenerateSchemaForType(t *testing.T) {
+	schema, err := jsonschema.GenerateSchemaForType(weatherParams{private: ""})
+	if err != nil {
+		t.Fatalf("GenerateSchemaForType error: %v", err)
+	}
+
+	want := `{
+		"type": "object",
+		"properties": {
+			"created_at": {"type": "string", "format": "date-time", "description": "Creation time", "properties": {}},
+			"author": {"type": "string", "properties": {}},
+			"location": {"type": "string", "description": "The city and state", "properties": {}},
+			"unit": {"type": "string", "enum": ["celsius", "fahrenheit"], "properties": {}},
+			"days": {"type": "integer", "enum": [1, 3, 7], "properties": {}},
+			"ratio": {"type": "number", "properties": {}},
+			"exact": {"type": "boolean", "properties": {}},
+			"home": {
+				"type": "object",
+				"properties": {"city": {"type": "string", "properties": {}}},
+				"required": ["city"]
+			},
+			"stops": {
+				"type": "array",
+				"properties": {},
+				"items": {
+					"type": "object",
+					"properties": {"city": {"type": "string", "properties": {}}},
+					"required": ["city"]
+				}
+			},
+			"labels": {"type": "object", "properties": {}, "additionalProperties": {"type": "string", "properties": {}}},
+			"raw": {"type": "string", "properties": {}},
+			"extra": {"properties": {}},
+			"Untagged": {"type": "string", "properties": {}}
+		},
+		"required": ["created_at", "location", "ratio", "exact", "stops", "Untagged"]
+	}`
+	got, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	if !equalJSON(t, got, []byte(want)) {
+		t.Errorf("unexpected schema:\n%s", got)
+	}
+}
+
+type Node struct {
+	Name     string `json:"name"`
+	Children []Node `json:"children,omitempty"`
+}
+
+type tree struct {
+	Root   Node  `json:"root"`
+	Parent *tree `json:"parent,omitempty"`
+}
+
+func TestGenerateSchemaForRecursiveType(t *testing.T) {
+	schema, err := jsonschema.GenerateSchemaForType(tree{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaForType error: %v", err)
+	}
+
+	want := `{
+		"type": "object",
+		"properties": {
+			"root": {"$ref": "#/$defs/Node", "properties": {}},
+			"parent": {"$ref": "#", "properties": {}}
+		},
+		"required": ["root"],
+		"$defs": {
+			"Node": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string", "properties": {}},
+					"children": {"type": "array", "items": {"$ref": "#/$defs/Node", "properties": {}}, "properties": {}}
+				},
+				"required": ["name"]
+			}
+		}
+	}`
+	got, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	if !equalJSON(t, got, []byte(want)) {
+		t.Errorf("unexpected schema:\n%s", got)
+	}
+
+	data := `{"root":{"name":"a","children":[{"name":"b","children":[{"name":1}]}]}}`
+	err = schema.Validate([]byte(data))
+	if err == nil || !strings.Contains(err.Error(), "$.root.children[0].children[0].name") {
+		t.Errorf("expected the recursive definition to be validated, got %v", err)
+	}
+}
+
+func TestGenerateSchemaForTypeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want error
+	}{
+		{"nil", nil, jsonschema.ErrUnsupportedType},
+		{"channel", make(chan int), jsonschema.ErrUnsupportedType},
+		{"function field", struct{ F func() }{}, jsonschema.ErrUnsupportedType},
+		{"integer enum", struct {
+			Level int `enum:"low,high"`
+		}{}, jsonschema.ErrInvalidEnum},
+		{"boolean enum", struct {
+			Exact bool `enum:"yes"`
+		}{}, jsonschema.ErrInvalidEnum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := jsonschema.GenerateSchemaForType(tt.v); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func equalJSON(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb any
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return string(ja) == string(jb)
+}
@@ -0,0 +1,303 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError reports the first value of a document that does not match its schema.
+type ValidationError struct {
+	// Path locates the value in the document, such as "$.stops[0].city".
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// Validate checks that data is a JSON document matching the schema: its type,
+// required properties, enum and const values, array items, nested and
+// additional properties, numeric bounds, string lengths and patterns, the
+// anyOf, oneOf and allOf combinations and references to the root schema or
+// its $defs.
+func (d Definition) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if decoder.More() {
+		return errors.New("invalid JSON: unexpected data after the top-level value")
+	}
+	return validator{root: &d}.validate(d, "$", value)
+}
+
+// VerifySchemaAndUnmarshal validates data against the schema, then unmarshals it into v.
+func VerifySchemaAndUnmarshal(schema Definition, data []byte, v any) error {
+	if err := schema.Validate(data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// validator validates a document against a root schema, which holds the
+// definitions referenced with $ref.
+type validator struct {
+	root *Definition
+}
+
+func (v validator) validate(d Definition, path string, value any) error {
+	if d.Ref != "" {
+		ref, err := v.resolve(d.Ref)
+		if err != nil {
+			return &ValidationError{Path: path, Message: err.Error()}
+		}
+		if err = v.validate(ref, path, value); err != nil {
+			return err
+		}
+	}
+	if value == nil && d.Nullable {
+		return nil
+	}
+	if d.Type != "" && !isType(d.Type, value) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("expected %s, got %s", d.Type, typeOf(value))}
+	}
+	if enum := d.enumValues(); len(enum) > 0 && !inEnum(enum, value) {
+		encoded, _ := json.Marshal(enum)
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be one of %s", encoded)}
+	}
+	if d.Const != nil && !equalJSON(d.Const, value) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be %v", d.Const)}
+	}
+	if err := v.validateCombinations(d, path, value); err != nil {
+		return err
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		return v.validateObject(d, path, value)
+	case []any:
+		if d.Items == nil {
+			return nil
+		}
+		for i, item := range value {
+			if err := v.validate(*d.Items, path+"["+strconv.Itoa(i)+"]", item); err != nil {
+				return err
+			}
+		}
+	case string:
+		return validateString(d, path, value)
+	case json.Number:
+		return validateNumber(d, path, value)
+	}
+	return nil
+}
+
+func (v validator) resolve(ref string) (Definition, error) {
+	if ref == "#" {
+		return *v.root, nil
+	}
+	if name := strings.TrimPrefix(ref, "#/$defs/"); name != ref {
+		if d, ok := v.root.Defs[name]; ok {
+			return d, nil
+		}
+	}
+	return Definition{}, fmt.Errorf("unresolved reference %q", ref)
+}
+
+func (v validator) validateCombinations(d Definition, path string, value any) error {
+	for _, schema := range d.AllOf {
+		if err := v.validate(schema, path, value); err != nil {
+			return err
+		}
+	}
+
+	if len(d.AnyOf) > 0 {
+		matched := false
+		for _, schema := range d.AnyOf {
+			if v.validate(schema, path, value) == nil {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return &ValidationError{Path: path, Message: "does not match any schema of anyOf"}
+		}
+	}
+
+	if len(d.OneOf) > 0 {
+		matches := 0
+		for _, schema := range d.OneOf {
+			if v.validate(schema, path, value) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("matches %d schemas of oneOf instead of one", matches)}
+		}
+	}
+	return nil
+}
+
+func (v validator) validateObject(d Definition, path string, object map[string]any) error {
+	for _, name := range d.Required {
+		if _, ok := object[name]; !ok {
+			return &ValidationError{Path: path, Message: fmt.Sprintf("missing required property %q", name)}
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := path + "." + name
+		if property, ok := d.Properties[name]; ok {
+			if err := v.validate(property, propertyPath, object[name]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch additional := d.AdditionalProperties.(type) {
+		case bool:
+			if !additional {
+				return &ValidationError{Path: propertyPath, Message: "additional property is not allowed"}
+			}
+		case Definition:
+			if err := v.validate(additional, propertyPath, object[name]); err != nil {
+				return err
+			}
+		case *Definition:
+			if err := v.validate(*additional, propertyPath, object[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateString(d Definition, path, s string) error {
+	length := utf8.RuneCountInString(s)
+	if d.MinLength != nil && length < *d.MinLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at least %d characters long", *d.MinLength)}
+	}
+	if d.MaxLength != nil && length > *d.MaxLength {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %d characters long", *d.MaxLength)}
+	}
+	if d.Pattern == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(d.Pattern)
+	if err != nil {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("invalid pattern %q: %v", d.Pattern, err)}
+	}
+	if !pattern.MatchString(s) {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must match %q", d.Pattern)}
+	}
+	return nil
+}
+
+func validateNumber(d Definition, path string, number json.Number) error {
+	f, err := number.Float64()
+	if err != nil {
+		return &ValidationError{Path: path, Message: err.Error()}
+	}
+	if d.Minimum != nil && f < *d.Minimum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at least %v", *d.Minimum)}
+	}
+	if d.Maximum != nil && f > *d.Maximum {
+		return &ValidationError{Path: path, Message: fmt.Sprintf("must be at most %v", *d.Maximum)}
+	}
+	return nil
+}
+
+// equalJSON reports whether a and b have the same JSON encoding.
+func equalJSON(a, b any) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+func isType(t DataType, value any) bool {
+	switch t {
+	case Object:
+		_, ok := value.(map[string]any)
+		return ok
+	case Array:
+		_, ok := value.([]any)
+		return ok
+	case String:
+		_, ok := value.(string)
+		return ok
+	case Boolean:
+		_, ok := value.(bool)
+		return ok
+	case Null:
+		return value == nil
+	case Number:
+		_, ok := value.(json.Number)
+		return ok
+	case Integer:
+		number, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		if _, err := number.Int64(); err == nil {
+			return true
+		}
+		f, err := number.Float64()
+		return err == nil && f == float64(int64(f))
+	default:
+		return true
+	}
+}
+
+func typeOf(value any) DataType {
+	switch value.(type) {
+	case map[string]any:
+		return Object
+	case []any:
+		return Array
+	case string:
+		return String
+	case bool:
+		return Boolean
+	case json.Number:
+		return Number
+	default:
+		return Null
+	}
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, member := range enum {
+		if equalJSON(member, value) || equalNumbers(member, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalNumbers reports whether a and b are numbers of the same value, such as 2 and 2.0.
+func equalNumbers(a, b any) bool {
+	numberA, okA := a.(json.Number)
+	numberB, okB := b.(json.Number)
+	if !okA || !okB {
+		return false
+	}
+	floatA, errA := numberA.Float64()
+	floatB, errB := numberB.Float64()
+	return errA == nil && errB == nil && floatA == floatB
+}
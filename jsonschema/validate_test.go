@@ -0,0 +1,145 @@
+package jsonschema_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai/jsonschema"
+)
+
+var testValidateSchema = jsonschema.Definition{
+	Type: jsonschema.Object,
+	Properties: map[string]jsonschema.Definition{
+		"location": {Type: jsonschema.String},
+		"unit":     {Type: jsonschema.String, Enum: []string{"celsius", "fahrenheit"}},
+		"days":     {Type: jsonschema.Integer},
+		"level":    {Type: jsonschema.Integer, Enum: []string{"1", "2", "3"}},
+		"exact":    {Type: jsonschema.Boolean, Enum: []string{"true"}},
+		"stops": {
+			Type: jsonschema.Array,
+			Items: &jsonschema.Definition{
+				Type:       jsonschema.Object,
+				Properties: map[string]jsonschema.Definition{"city": {Type: jsonschema.String}},
+				Required:   []string{"city"},
+			},
+		},
+	},
+	Required:             []string{"location"},
+	AdditionalProperties: false,
+}
+
+func TestDefinitionValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		path string
+	}{
+		{"valid", `{"location":"Beijing","unit":"celsius","days":3,"stops":[{"city":"Tianjin"}]}`, ""},
+		{"integral float", `{"location":"Beijing","days":3.0}`, ""},
+		{"wrong root type", `[]`, "$"},
+		{"missing required", `{"unit":"celsius"}`, "$"},
+		{"wrong property type", `{"location":42}`, "$.location"},
+		{"not in enum", `{"location":"Beijing","unit":"kelvin"}`, "$.unit"},
+		{"integer enum", `{"location":"Beijing","level":2,"exact":true}`, ""},
+		{"integral float enum", `{"location":"Beijing","level":2.0}`, ""},
+		{"not in integer enum", `{"location":"Beijing","level":4}`, "$.level"},
+		{"string in integer enum", `{"location":"Beijing","level":"2"}`, "$.level"},
+		{"not in boolean enum", `{"location":"Beijing","exact":false}`, "$.exact"},
+		{"not an integer", `{"location":"Beijing","days":1.5}`, "$.days"},
+		{"nested item", `{"location":"Beijing","stops":[{"city":"Tianjin"},{"city":1}]}`, "$.stops[1].city"},
+		{"nested required", `{"location":"Beijing","stops":[{}]}`, "$.stops[0]"},
+		{"additional property", `{"location":"Beijing","extra":true}`, "$.extra"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := testValidateSchema.Validate([]byte(tt.data))
+			if tt.path == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var validationErr *jsonschema.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Path != tt.path {
+				t.Errorf("expected a validation error at %s, got %v", tt.path, err)
+			}
+		})
+	}
+}
+
+func TestVerifySchemaAndUnmarshal(t *testing.T) {
+	var params struct {
+		Location string `json:"location"`
+		Days     int    `json:"days"`
+	}
+	err := jsonschema.VerifySchemaAndUnmarshal(testValidateSchema, []byte(`{"location":"Beijing","days":3}`), &params)
+	if err != nil || params.Location != "Beijing" || params.Days != 3 {
+		t.Errorf("unexpected result %+v: %v", params, err)
+	}
+
+	err = jsonschema.VerifySchemaAndUnmarshal(testValidateSchema, []byte(`{"location":`), &params)
+	if err == nil {
+		t.Error("expected malformed JSON to be rejected")
+	}
+	err = jsonschema.VerifySchemaAndUnmarshal(testValidateSchema, []byte(`{"days":3}`), &params)
+	if err == nil || err.Error() != `$: missing required property "location"` {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDefinitionValidateKeywords(t *testing.T) {
+	minimum, maximum, maxLength := 1.0, 10.0, 5
+	schema := jsonschema.Definition{
+		Type: jsonschema.Object,
+		Properties: map[string]jsonschema.Definition{
+			"name":  {Type: jsonschema.String, Nullable: true, MaxLength: &maxLength, Pattern: "^[a-z]+$"},
+			"count": {Type: jsonschema.Integer, Minimum: &minimum, Maximum: &maximum},
+			"kind":  {Const: "item"},
+			"value": {AnyOf: []jsonschema.Definition{{Type: jsonschema.String}, {Ref: "#/$defs/count"}}},
+			"id": {OneOf: []jsonschema.Definition{
+				{Type: jsonschema.Integer},
+				{Type: jsonschema.Number, Minimum: &maximum},
+			}},
+			"tags": {AllOf: []jsonschema.Definition{
+				{Type: jsonschema.Array},
+				{Items: &jsonschema.Definition{Type: jsonschema.String}},
+			}},
+		},
+		Defs: map[string]jsonschema.Definition{
+			"count": {Type: jsonschema.Integer},
+		},
+	}
+
+	tests := []struct {
+		name string
+		data string
+		path string
+	}{
+		{"valid", `{"name":"abc","count":3,"kind":"item","value":2,"id":1,"tags":["a"]}`, ""},
+		{"null", `{"name":null}`, ""},
+		{"too long", `{"name":"abcdef"}`, "$.name"},
+		{"pattern", `{"name":"ABC"}`, "$.name"},
+		{"minimum", `{"count":0}`, "$.count"},
+		{"maximum", `{"count":11}`, "$.count"},
+		{"const", `{"kind":"other"}`, "$.kind"},
+		{"anyOf", `{"value":1.5}`, "$.value"},
+		{"oneOf matches none", `{"id":2.5}`, "$.id"},
+		{"oneOf matches several", `{"id":20}`, "$.id"},
+		{"allOf", `{"tags":[1]}`, "$.tags[0]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate([]byte(tt.data))
+			if tt.path == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var validationErr *jsonschema.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Path != tt.path {
+				t.Errorf("expected a validation error at %s, got %v", tt.path, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package zhipuai
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultKeyCooldown = time.Minute
+	// maxCredentialFailovers bounds how many times a single request is re-sent
+	// with other credentials after a 401 or 429 response.
+	maxCredentialFailovers = 10
+)
+
+// CredentialObserver may be implemented by a CredentialProvider to learn the
+// outcome of the requests sent with its tokens.
+type CredentialObserver interface {
+	// ObserveResponse is called after every attempt with the token that was sent
+	// and the response status code, or 0 on transport errors. It reports whether
+	// the request should be sent again with fresh credentials.
+	ObserveResponse(token string, statusCode int) bool
+	// ObserveUsage is called with the usage reported by a successful response.
+	ObserveUsage(token string, usage Usage)
+}
+
+// KeyPoolStrategy selects which key of a KeyPool serves the next request.
+type KeyPoolStrategy string
+
+const (
+	// KeyPoolRoundRobin cycles through the keys in order.
+	KeyPoolRoundRobin KeyPoolStrategy = "round_robin"
+	// KeyPoolLeastLoaded picks the key that has consumed the fewest tokens.
+	KeyPoolLeastLoaded KeyPoolStrategy = "least_loaded"
+)
+
+// KeyUsage holds the counters of a single key of a KeyPool.
+type KeyUsage struct {
+	// KeyID identifies the key without exposing its secret.
+	KeyID            string
+	Requests         int64
+	Failures         int64
+	PromptTokens     int64
+	CompletionTokens int64
+	TotalTokens      int64
+	// CoolingDownUntil is set while the key is skipped after a 401 or 429 response.
+	CoolingDownUntil time.Time
+}
+
+type pooledKey struct {
+	apiKey string
+	// token and previousToken are the credentials most recently issued for the key;
+	// the previous one keeps in-flight requests attributable across JWT renewals.
+	token         string
+	previousToken string
+	usage         KeyUsage
+}
+
+// KeyPool spreads requests across several API keys. Keys in the ZhipuAI
+// "id.secret" format are signed into JWTs, other keys are sent verbatim.
+// A key answered with 401 or 429 cools down and the request fails over to the
+// next available key.
+//
+// Use it as ClientConfig.CredentialProvider.
+type KeyPool struct {
+	// Strategy selects the next key. Defaults to KeyPoolRoundRobin.
+	Strategy KeyPoolStrategy
+	// Cooldown is how long a key is skipped after a 401 or 429 response.
+	// Defaults to one minute.
+	Cooldown time.Duration
+
+	mu     sync.Mutex
+	keys   []*pooledKey
+	next   int
+	signer *JWTCredentials
+}
+
+// NewKeyPool creates a round-robin pool over the given API keys.
+func NewKeyPool(apiKeys ...string) *KeyPool {
+	pool := &KeyPool{
+		Strategy: KeyPoolRoundRobin,
+		Cooldown: defaultKeyCooldown,
+		signer:   NewJWTCredentials(nil),
+	}
+	for _, apiKey := range apiKeys {
+		pool.keys = append(pool.keys, &pooledKey{
+			apiKey: apiKey,
+			usage:  KeyUsage{KeyID: keyID(apiKey)},
+		})
+	}
+	return pool
+}
+
+// keyID returns the public part of an "id.secret" key, or a masked suffix of other keys.
+func keyID(apiKey string) string {
+	if id, _, found := strings.Cut(apiKey, "."); found {
+		return id
+	}
+	const visible = 4
+	if len(apiKey) <= visible {
+		return "..."
+	}
+	return "..." + apiKey[len(apiKey)-visible:]
+}
+
+func (p *KeyPool) Token(context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := p.pick(time.Now())
+	if key == nil {
+		return "", ErrMissingAPIKey
+	}
+
+	token := key.apiKey
+	if strings.Contains(key.apiKey, ".") {
+		var err error
+		token, err = p.signer.token(key.apiKey)
+		if err != nil {
+			return "", err
+		}
+	}
+	if token != key.token {
+		key.previousToken, key.token = key.token, token
+	}
+	key.usage.Requests++
+	return token, nil
+}
+
+// pick selects the next key according to the strategy, skipping keys that are
+// cooling down. When every key is cooling down, the one available soonest is used.
+func (p *KeyPool) pick(now time.Time) *pooledKey {
+	if len(p.keys) == 0 {
+		return nil
+	}
+
+	var best *pooledKey
+	for i := range p.keys {
+		idx := (p.next + i) % len(p.keys)
+		key := p.keys[idx]
+		if now.Before(key.usage.CoolingDownUntil) {
+			continue
+		}
+		if p.Strategy != KeyPoolLeastLoaded {
+			p.next = idx + 1
+			return key
+		}
+		if best == nil || key.usage.TotalTokens < best.usage.TotalTokens ||
+			(key.usage.TotalTokens == best.usage.TotalTokens && key.usage.Requests < best.usage.Requests) {
+			best = key
+		}
+	}
+	if best != nil {
+		return best
+	}
+
+	for _, key := range p.keys {
+		if best == nil || key.usage.CoolingDownUntil.Before(best.usage.CoolingDownUntil) {
+			best = key
+		}
+	}
+	return best
+}
+
+func (p *KeyPool) findKey(token string) *pooledKey {
+	for _, key := range p.keys {
+		if key.token == token || key.previousToken == token {
+			return key
+		}
+	}
+	return nil
+}
+
+func (p *KeyPool) ObserveResponse(token string, statusCode int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := p.findKey(token)
+	if key == nil {
+		return false
+	}
+	if statusCode != 0 && !isFailureStatusCode(&http.Response{StatusCode: statusCode}) {
+		return false
+	}
+	key.usage.Failures++
+	if statusCode != http.StatusUnauthorized && statusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	now := time.Now()
+	cooldown := p.Cooldown
+	if cooldown <= 0 {
+		cooldown = defaultKeyCooldown
+	}
+	key.usage.CoolingDownUntil = now.Add(cooldown)
+
+	for _, other := range p.keys {
+		if !now.Before(other.usage.CoolingDownUntil) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *KeyPool) ObserveUsage(token string, usage Usage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := p.findKey(token)
+	if key == nil {
+		return
+	}
+	key.usage.PromptTokens += int64(usage.PromptTokens)
+	key.usage.CompletionTokens += int64(usage.CompletionTokens)
+	key.usage.TotalTokens += int64(usage.TotalTokens)
+}
+
+// Usage returns a snapshot of the per-key counters, in the order the keys were added.
+func (p *KeyPool) Usage() []KeyUsage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	usage := make([]KeyUsage, len(p.keys))
+	for i, key := range p.keys {
+		usage[i] = key.usage
+	}
+	return usage
+}
+
+// requestToken returns the credential a request was sent with.
+func requestToken(req *http.Request) string {
+	if token := req.Header.Get(AzureAPIKeyHeader); token != "" {
+		return token
+	}
+	return strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+}
+
+// observeResponse reports the outcome of an attempt to the credential provider
+// and returns whether the request should be re-sent with other credentials.
+func (c *Client) observeResponse(req *http.Request, resp *http.Response) bool {
+	observer, ok := c.credentials.(CredentialObserver)
+	if !ok {
+		return false
+	}
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	return observer.ObserveResponse(requestToken(req), statusCode)
+}
+
+// observeUsage reports the usage of a successful response to the credential provider.
+func (c *Client) observeUsage(resp *http.Response, v any) {
+	observer, ok := c.credentials.(CredentialObserver)
+	if !ok || resp.Request == nil {
+		return
+	}
+	if usage, hasUsage := responseUsage(v); hasUsage {
+		observer.ObserveUsage(requestToken(resp.Request), usage)
+	}
+}
+
+// observeStreamUsage reports the usage of a finished stream to the credential provider.
+func (c *Client) observeStreamUsage(req *http.Request, usage Usage) {
+	if observer, ok := c.credentials.(CredentialObserver); ok {
+		observer.ObserveUsage(requestToken(req), usage)
+	}
+}
@@ -0,0 +1,81 @@
+package zhipuai_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+func TestKeyPoolRoundRobin(t *testing.T) {
+	pool := zhipuai.NewKeyPool("key-a", "key-b", "key-c")
+	expected := []string{"key-a", "key-b", "key-c", "key-a"}
+	for i, want := range expected {
+		token, err := pool.Token(context.Background())
+		checks.NoError(t, err, "Token error")
+		if token != want {
+			t.Errorf("request %d: expected %s, got %s", i, want, token)
+		}
+	}
+}
+
+func TestKeyPoolLeastLoaded(t *testing.T) {
+	pool := zhipuai.NewKeyPool("key-a", "key-b")
+	pool.Strategy = zhipuai.KeyPoolLeastLoaded
+
+	token, err := pool.Token(context.Background())
+	checks.NoError(t, err, "Token error")
+	pool.ObserveUsage(token, zhipuai.Usage{TotalTokens: 100})
+
+	next, err := pool.Token(context.Background())
+	checks.NoError(t, err, "Token error")
+	if next == token {
+		t.Errorf("expected the key with less usage to be picked, got %s again", next)
+	}
+}
+
+func TestKeyPoolSignsJWTKeys(t *testing.T) {
+	pool := zhipuai.NewKeyPool(testJWTKey)
+	token, err := pool.Token(context.Background())
+	checks.NoError(t, err, "Token error")
+	if token == testJWTKey {
+		t.Errorf("expected id.secret key to be signed")
+	}
+	if id := pool.Usage()[0].KeyID; id != "my-key-id" {
+		t.Errorf("expected key id my-key-id, got %q", id)
+	}
+}
+
+func TestKeyPoolEmpty(t *testing.T) {
+	_, err := zhipuai.NewKeyPool().Token(context.Background())
+	checks.ErrorIs(t, err, zhipuai.ErrMissingAPIKey, "empty pool should not yield a token")
+}
+
+func TestKeyPoolFailover(t *testing.T) {
+	pool := zhipuai.NewKeyPool("revoked-key", test.GetTestToken())
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.CredentialProvider = pool
+	})
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"id":"1","object":"chat.completion","choices":[],`+
+			`"usage":{"prompt_tokens":3,"completion_tokens":4,"total_tokens":7}}`)
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+		checks.NoError(t, err, "CreateChatCompletion should fail over to the working key")
+	}
+
+	usage := pool.Usage()
+	if usage[0].Failures != 1 || usage[0].CoolingDownUntil.IsZero() {
+		t.Errorf("expected the revoked key to cool down after one failure, got %+v", usage[0])
+	}
+	if usage[1].Requests != 2 || usage[1].TotalTokens != 14 || usage[1].PromptTokens != 6 {
+		t.Errorf("expected the working key to serve both requests, got %+v", usage[1])
+	}
+}
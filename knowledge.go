@@ -0,0 +1,342 @@
+package zhipuai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+const (
+	knowledgeSuffix         = "/knowledge"
+	knowledgeCapacitySuffix = "/knowledge/capacity"
+	knowledgeDocumentSuffix = "/document"
+)
+
+var ErrKnowledgeDocumentMissingFile = errors.New("either FilePath or Bytes must be set to upload a knowledge document") //nolint:lll
+
+// KnowledgeEmbedding is the embedding model a knowledge base is indexed with.
+type KnowledgeEmbedding int
+
+const (
+	KnowledgeEmbedding2 KnowledgeEmbedding = 3
+	KnowledgeEmbedding3 KnowledgeEmbedding = 11
+)
+
+// Knowledge is a knowledge base that can be queried with a retrieval tool.
+type Knowledge struct {
+	ID          string             `json:"id"`
+	EmbeddingID KnowledgeEmbedding `json:"embedding_id,omitempty"`
+	Name        string             `json:"name,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Background  string             `json:"background,omitempty"`
+	Icon        string             `json:"icon,omitempty"`
+	// DocumentSize is the number of documents in the knowledge base.
+	DocumentSize int `json:"document_size,omitempty"`
+	// Length is the total size of the documents in bytes.
+	Length  int64 `json:"length,omitempty"`
+	WordNum int64 `json:"word_num,omitempty"`
+
+	httpHeader
+}
+
+// KnowledgeRequest represents a request to create or update a knowledge base.
+type KnowledgeRequest struct {
+	EmbeddingID KnowledgeEmbedding `json:"embedding_id,omitempty"`
+	Name        string             `json:"name,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Background  string             `json:"background,omitempty"`
+	Icon        string             `json:"icon,omitempty"`
+}
+
+// KnowledgeList is a page of knowledge bases.
+type KnowledgeList struct {
+	Knowledge []Knowledge `json:"list"`
+	Total     int         `json:"total"`
+
+	httpHeader
+}
+
+// KnowledgeUsage is the amount of text stored in knowledge bases.
+type KnowledgeUsage struct {
+	WordNum int64 `json:"word_num"`
+	Length  int64 `json:"length"`
+}
+
+// KnowledgeCapacity is the storage used by the account and its quota.
+type KnowledgeCapacity struct {
+	Used  KnowledgeUsage `json:"used"`
+	Total KnowledgeUsage `json:"total"`
+
+	httpHeader
+}
+
+// KnowledgeDocumentRequest represents a document upload into a knowledge base.
+type KnowledgeDocumentRequest struct {
+	KnowledgeID string
+	// FileName is the name of the document. It defaults to the base name of FilePath.
+	FileName string
+	// FilePath is a local file to upload. It is ignored when Bytes is set.
+	FilePath string
+	Bytes    []byte
+	// SentenceSize is the length of the chunks the document is split into,
+	// 0 lets the server decide.
+	SentenceSize int
+}
+
+// KnowledgeDocumentUploaded describes a document that was accepted.
+type KnowledgeDocumentUploaded struct {
+	DocumentID string `json:"documentId"`
+	FileName   string `json:"fileName"`
+}
+
+// KnowledgeDocumentRejected describes a document that could not be uploaded.
+type KnowledgeDocumentRejected struct {
+	FileName   string `json:"fileName"`
+	FailReason string `json:"failReason"`
+}
+
+// KnowledgeDocumentUploadResponse reports the outcome of a document upload.
+type KnowledgeDocumentUploadResponse struct {
+	Uploaded []KnowledgeDocumentUploaded `json:"successInfos"`
+	Rejected []KnowledgeDocumentRejected `json:"failedInfos"`
+
+	httpHeader
+}
+
+// KnowledgeDocumentFailure explains why a document could not be indexed.
+type KnowledgeDocumentFailure struct {
+	EmbeddingCode int    `json:"embedding_code"`
+	EmbeddingMsg  string `json:"embedding_msg"`
+}
+
+// KnowledgeDocument is a document stored in a knowledge base.
+type KnowledgeDocument struct {
+	ID          string `json:"id"`
+	KnowledgeID string `json:"knowledge_id,omitempty"`
+	Name        string `json:"name"`
+	URL         string `json:"url,omitempty"`
+	Length      int64  `json:"length,omitempty"`
+	WordNum     int64  `json:"word_num,omitempty"`
+	// EmbeddingStat is the indexing state of the document.
+	EmbeddingStat int                       `json:"embedding_stat,omitempty"`
+	FailureInfo   *KnowledgeDocumentFailure `json:"failInfo,omitempty"`
+	SentenceSize  int                       `json:"sentence_size,omitempty"`
+
+	httpHeader
+}
+
+// KnowledgeDocumentList is a page of documents of a knowledge base.
+type KnowledgeDocumentList struct {
+	Documents []KnowledgeDocument `json:"list"`
+	Total     int                 `json:"total"`
+
+	httpHeader
+}
+
+// knowledgeEnvelope is the wrapper the knowledge API puts around its payloads.
+type knowledgeEnvelope struct {
+	Data any `json:"data"`
+
+	httpHeader
+}
+
+// sendKnowledgeRequest unwraps the data field of a knowledge API response into v.
+func (c *Client) sendKnowledgeRequest(req *http.Request, v Response) error {
+	envelope := knowledgeEnvelope{}
+	if v != nil {
+		envelope.Data = v
+	}
+	if err := c.sendRequest(req, &envelope); err != nil {
+		return err
+	}
+	if v != nil {
+		v.SetHeader(envelope.Header())
+	}
+	return nil
+}
+
+// pageQuery encodes the optional page and size parameters of list endpoints.
+func pageQuery(urlValues url.Values, page, size *int) string {
+	if page != nil {
+		urlValues.Add("page", strconv.Itoa(*page))
+	}
+	if size != nil {
+		urlValues.Add("size", strconv.Itoa(*size))
+	}
+	if len(urlValues) == 0 {
+		return ""
+	}
+	return "?" + urlValues.Encode()
+}
+
+// CreateKnowledge creates a new knowledge base.
+func (c *Client) CreateKnowledge(ctx context.Context, request KnowledgeRequest) (response Knowledge, err error) {
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL(knowledgeSuffix), withBody(request))
+	if err != nil {
+		return
+	}
+
+	err = c.sendKnowledgeRequest(req, &response)
+	return
+}
+
+// ListKnowledge lists the knowledge bases of the account.
+func (c *Client) ListKnowledge(ctx context.Context, page, size *int) (response KnowledgeList, err error) {
+	urlSuffix := knowledgeSuffix + pageQuery(url.Values{}, page, size)
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendKnowledgeRequest(req, &response)
+	return
+}
+
+// UpdateKnowledge modifies a knowledge base.
+func (c *Client) UpdateKnowledge(ctx context.Context, knowledgeID string, request KnowledgeRequest) (err error) {
+	urlSuffix := fmt.Sprintf("%s/%s", knowledgeSuffix, knowledgeID)
+	req, err := c.newRequest(ctx, http.MethodPut, c.fullURL(urlSuffix), withBody(request))
+	if err != nil {
+		return
+	}
+
+	err = c.sendKnowledgeRequest(req, nil)
+	return
+}
+
+// DeleteKnowledge deletes a knowledge base and its documents.
+func (c *Client) DeleteKnowledge(ctx context.Context, knowledgeID string) (err error) {
+	urlSuffix := fmt.Sprintf("%s/%s", knowledgeSuffix, knowledgeID)
+	req, err := c.newRequest(ctx, http.MethodDelete, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendKnowledgeRequest(req, nil)
+	return
+}
+
+// GetKnowledgeCapacity returns the knowledge base storage used by the account.
+func (c *Client) GetKnowledgeCapacity(ctx context.Context) (response KnowledgeCapacity, err error) {
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(knowledgeCapacitySuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendKnowledgeRequest(req, &response)
+	return
+}
+
+// UploadKnowledgeDocument uploads a document into a knowledge base.
+func (c *Client) UploadKnowledgeDocument(
+	ctx context.Context,
+	request KnowledgeDocumentRequest,
+) (response KnowledgeDocumentUploadResponse, err error) {
+	var b bytes.Buffer
+	builder := c.createFormBuilder(&b)
+
+	err = builder.WriteField("purpose", string(PurposeRetrieval))
+	if err != nil {
+		return
+	}
+
+	err = builder.WriteField("knowledge_id", request.KnowledgeID)
+	if err != nil {
+		return
+	}
+
+	if request.SentenceSize > 0 {
+		err = builder.WriteField("sentence_size", strconv.Itoa(request.SentenceSize))
+		if err != nil {
+			return
+		}
+	}
+
+	switch {
+	case request.Bytes != nil:
+		err = builder.CreateFormFileReader("file", bytes.NewReader(request.Bytes), request.FileName)
+	case request.FilePath != "":
+		var file *os.File
+		file, err = os.Open(request.FilePath)
+		if err != nil {
+			return
+		}
+		defer file.Close()
+		fileName := request.FileName
+		if fileName == "" {
+			fileName = request.FilePath
+		}
+		err = builder.CreateFormFileReader("file", file, fileName)
+	default:
+		err = ErrKnowledgeDocumentMissingFile
+	}
+	if err != nil {
+		return
+	}
+
+	err = builder.Close()
+	if err != nil {
+		return
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPost, c.fullURL("/files"),
+		withBody(&b), withContentType(builder.FormDataContentType()))
+	if err != nil {
+		return
+	}
+
+	err = c.sendKnowledgeRequest(req, &response)
+	return
+}
+
+// ListKnowledgeDocuments lists the documents of a knowledge base.
+func (c *Client) ListKnowledgeDocuments(
+	ctx context.Context,
+	knowledgeID string,
+	page *int,
+	size *int,
+) (response KnowledgeDocumentList, err error) {
+	urlValues := url.Values{}
+	urlValues.Add("purpose", string(PurposeRetrieval))
+	urlValues.Add("knowledge_id", knowledgeID)
+	urlSuffix := "/files" + pageQuery(urlValues, page, size)
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendKnowledgeRequest(req, &response)
+	return
+}
+
+// RetrieveKnowledgeDocument retrieves a document of a knowledge base.
+func (c *Client) RetrieveKnowledgeDocument(
+	ctx context.Context,
+	documentID string,
+) (response KnowledgeDocument, err error) {
+	urlSuffix := fmt.Sprintf("%s/%s", knowledgeDocumentSuffix, documentID)
+	req, err := c.newRequest(ctx, http.MethodGet, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendKnowledgeRequest(req, &response)
+	return
+}
+
+// DeleteKnowledgeDocument deletes a document from its knowledge base.
+func (c *Client) DeleteKnowledgeDocument(ctx context.Context, documentID string) (err error) {
+	urlSuffix := fmt.Sprintf("%s/%s", knowledgeDocumentSuffix, documentID)
+	req, err := c.newRequest(ctx, http.MethodDelete, c.fullURL(urlSuffix))
+	if err != nil {
+		return
+	}
+
+	err = c.sendKnowledgeRequest(req, nil)
+	return
+}
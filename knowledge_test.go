@@ -0,0 +1,154 @@
+package zhipuai_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+func TestKnowledge(t *testing.T) {
+	const knowledgeID = "kb-1"
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+
+	server.RegisterHandler("/v1/knowledge", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			var request zhipuai.KnowledgeRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, "could not read request", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"code":200,"message":"ok","data":{"id":%q,"name":%q}}`, knowledgeID, request.Name)
+		case http.MethodGet:
+			if r.URL.Query().Get("page") != "2" || r.URL.Query().Get("size") != "10" {
+				http.Error(w, "unexpected query", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"code":200,"data":{"list":[{"id":%q,"document_size":3}],"total":11}}`, knowledgeID)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	server.RegisterHandler("/v1/knowledge/"+knowledgeID, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprintln(w, `{"code":200,"message":"ok"}`)
+	})
+	server.RegisterHandler("/v1/knowledge/capacity", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"code":200,"data":{"used":{"word_num":10,"length":40},"total":{"word_num":1000,"length":4000}}}`)
+	})
+
+	ctx := context.Background()
+	knowledge, err := client.CreateKnowledge(ctx, zhipuai.KnowledgeRequest{
+		EmbeddingID: zhipuai.KnowledgeEmbedding3,
+		Name:        "docs",
+	})
+	checks.NoError(t, err, "CreateKnowledge error")
+	if knowledge.ID != knowledgeID || knowledge.Name != "docs" {
+		t.Errorf("unexpected knowledge: %+v", knowledge)
+	}
+
+	page, size := 2, 10
+	list, err := client.ListKnowledge(ctx, &page, &size)
+	checks.NoError(t, err, "ListKnowledge error")
+	if list.Total != 11 || len(list.Knowledge) != 1 || list.Knowledge[0].DocumentSize != 3 {
+		t.Errorf("unexpected knowledge list: %+v", list)
+	}
+
+	err = client.UpdateKnowledge(ctx, knowledgeID, zhipuai.KnowledgeRequest{Description: "updated"})
+	checks.NoError(t, err, "UpdateKnowledge error")
+
+	err = client.DeleteKnowledge(ctx, knowledgeID)
+	checks.NoError(t, err, "DeleteKnowledge error")
+
+	capacity, err := client.GetKnowledgeCapacity(ctx)
+	checks.NoError(t, err, "GetKnowledgeCapacity error")
+	if capacity.Used.WordNum != 10 || capacity.Total.Length != 4000 {
+		t.Errorf("unexpected capacity: %+v", capacity)
+	}
+}
+
+func TestKnowledgeDocuments(t *testing.T) {
+	const knowledgeID = "kb-1"
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+
+	server.RegisterHandler("/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			if err := r.ParseMultipartForm(1024 * 1024); err != nil {
+				http.Error(w, "could not read form", http.StatusBadRequest)
+				return
+			}
+			if r.FormValue("purpose") != "retrieval" || r.FormValue("knowledge_id") != knowledgeID ||
+				r.FormValue("sentence_size") != "300" {
+				http.Error(w, "unexpected form", http.StatusBadRequest)
+				return
+			}
+			_, header, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, "missing file", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(w, `{"code":200,"data":{"successInfos":[{"documentId":"doc-1","fileName":%q}],"failedInfos":[]}}`,
+				header.Filename)
+		case http.MethodGet:
+			if r.URL.Query().Get("purpose") != "retrieval" || r.URL.Query().Get("knowledge_id") != knowledgeID {
+				http.Error(w, "unexpected query", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintln(w, `{"code":200,"data":{"list":[{"id":"doc-1","name":"faq.md","embedding_stat":2}],"total":1}}`)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	server.RegisterHandler("/v1/document/doc-1", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fmt.Fprintln(w, `{"code":200,"data":{"id":"doc-1","name":"faq.md","failInfo":{"embedding_code":0}}}`)
+		case http.MethodDelete:
+			fmt.Fprintln(w, `{"code":200,"message":"ok"}`)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	ctx := context.Background()
+	_, err := client.UploadKnowledgeDocument(ctx, zhipuai.KnowledgeDocumentRequest{KnowledgeID: knowledgeID})
+	checks.ErrorIs(t, err, zhipuai.ErrKnowledgeDocumentMissingFile, "upload without content should fail")
+
+	uploaded, err := client.UploadKnowledgeDocument(ctx, zhipuai.KnowledgeDocumentRequest{
+		KnowledgeID:  knowledgeID,
+		FileName:     "faq.md",
+		Bytes:        []byte("# FAQ"),
+		SentenceSize: 300,
+	})
+	checks.NoError(t, err, "UploadKnowledgeDocument error")
+	if len(uploaded.Uploaded) != 1 || uploaded.Uploaded[0].DocumentID != "doc-1" ||
+		uploaded.Uploaded[0].FileName != "faq.md" {
+		t.Errorf("unexpected upload response: %+v", uploaded)
+	}
+
+	documents, err := client.ListKnowledgeDocuments(ctx, knowledgeID, nil, nil)
+	checks.NoError(t, err, "ListKnowledgeDocuments error")
+	if documents.Total != 1 || documents.Documents[0].Name != "faq.md" {
+		t.Errorf("unexpected documents: %+v", documents)
+	}
+
+	document, err := client.RetrieveKnowledgeDocument(ctx, "doc-1")
+	checks.NoError(t, err, "RetrieveKnowledgeDocument error")
+	if document.ID != "doc-1" || document.FailureInfo == nil {
+		t.Errorf("unexpected document: %+v", document)
+	}
+
+	err = client.DeleteKnowledgeDocument(ctx, "doc-1")
+	checks.NoError(t, err, "DeleteKnowledgeDocument error")
+}
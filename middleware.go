@@ -0,0 +1,133 @@
+package zhipuai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// Request is an outgoing API call as seen by a Middleware.
+type Request struct {
+	// HTTPRequest is the encoded request about to be sent.
+	HTTPRequest *http.Request
+	// Endpoint is the API path relative to the base URL, e.g. "/chat/completions".
+	Endpoint string
+	// Model is the model named in the request body, if any.
+	Model string
+	// Body is the typed request value (e.g. ChatCompletionRequest) the HTTP body was encoded from.
+	// It is nil for requests without a body and for multipart uploads.
+	Body any
+}
+
+// SetBody replaces the typed body and re-encodes it into HTTPRequest as JSON.
+func (r *Request) SetBody(body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	r.HTTPRequest.Body = io.NopCloser(bytes.NewReader(data))
+	r.HTTPRequest.ContentLength = int64(len(data))
+	r.HTTPRequest.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	r.Body = body
+	return nil
+}
+
+// RoundTripFunc sends a Request and returns the raw HTTP response.
+type RoundTripFunc func(req *Request) (*http.Response, error)
+
+// Middleware wraps the transport of every request sent by the client. Middlewares
+// run in the order they are listed in ClientConfig.Middlewares, the first one being
+// the outermost, and are invoked once per attempt when a RetryPolicy is configured.
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+type requestInfoKey struct{}
+
+type requestInfo struct {
+	endpoint string
+	model    string
+	body     any
+}
+
+// withRequestInfo records the endpoint, model and typed body of a request so
+// that middlewares can inspect them later.
+func (c *Client) withRequestInfo(ctx context.Context, rawURL string, body any) context.Context {
+	info := requestInfo{
+		endpoint: c.endpointFromURL(rawURL),
+		model:    modelOf(body),
+	}
+	if _, isReader := body.(io.Reader); !isReader {
+		info.body = body
+	}
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// endpointFromURL strips the base URL and, for Azure, the deployment prefix from rawURL.
+func (c *Client) endpointFromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	path := u.Path
+	if base, baseErr := url.Parse(c.config.BaseURL); baseErr == nil {
+		path = strings.TrimPrefix(path, strings.TrimRight(base.Path, "/"))
+	}
+	if c.config.APIType == APITypeAzure || c.config.APIType == APITypeAzureAD {
+		path = strings.TrimPrefix(path, "/"+azureAPIPrefix)
+		deployments := "/" + azureDeploymentsPrefix + "/"
+		if strings.HasPrefix(path, deployments) {
+			path = strings.TrimPrefix(path, deployments)
+			if i := strings.Index(path, "/"); i >= 0 {
+				path = path[i:]
+			}
+		}
+	}
+	return path
+}
+
+// modelOf returns the value of the Model field of a request struct, if any.
+func modelOf(body any) string {
+	v := reflect.ValueOf(body)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	field := v.FieldByName("Model")
+	if !field.IsValid() || field.Kind() != reflect.String {
+		return ""
+	}
+	return field.String()
+}
+
+// roundTrip sends req through the configured middlewares and HTTP client.
+func (c *Client) roundTrip(req *http.Request) (*http.Response, error) {
+	if len(c.config.Middlewares) == 0 {
+		return c.config.HTTPClient.Do(req)
+	}
+
+	next := func(r *Request) (*http.Response, error) {
+		return c.config.HTTPClient.Do(r.HTTPRequest)
+	}
+	for i := len(c.config.Middlewares) - 1; i >= 0; i-- {
+		next = c.config.Middlewares[i](next)
+	}
+
+	info, _ := req.Context().Value(requestInfoKey{}).(requestInfo)
+	return next(&Request{
+		HTTPRequest: req,
+		Endpoint:    info.endpoint,
+		Model:       info.model,
+		Body:        info.body,
+	})
+}
@@ -0,0 +1,113 @@
+package zhipuai_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+func TestMiddlewareSeesTypedRequest(t *testing.T) {
+	var (
+		calls []string
+		seen  *zhipuai.Request
+	)
+	tracer := func(name string) zhipuai.Middleware {
+		return func(next zhipuai.RoundTripFunc) zhipuai.RoundTripFunc {
+			return func(req *zhipuai.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				seen = req
+				return next(req)
+			}
+		}
+	}
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.Middlewares = []zhipuai.Middleware{tracer("outer"), tracer("inner")}
+	})
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", handleChatCompletionEndpoint)
+
+	_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	if len(calls) != 2 || calls[0] != "outer" || calls[1] != "inner" {
+		t.Fatalf("unexpected middleware order: %v", calls)
+	}
+	if seen.Endpoint != "/chat/completions" {
+		t.Errorf("expected endpoint /chat/completions, got %q", seen.Endpoint)
+	}
+	if seen.Model != testChatCompletionRequest.Model {
+		t.Errorf("expected model %q, got %q", testChatCompletionRequest.Model, seen.Model)
+	}
+	if _, ok := seen.Body.(zhipuai.ChatCompletionRequest); !ok {
+		t.Errorf("expected typed ChatCompletionRequest body, got %T", seen.Body)
+	}
+}
+
+func TestMiddlewareRewritesRequest(t *testing.T) {
+	const redacted = "[redacted]"
+	redact := func(next zhipuai.RoundTripFunc) zhipuai.RoundTripFunc {
+		return func(req *zhipuai.Request) (*http.Response, error) {
+			req.HTTPRequest.Header.Set(xCustomHeader, xCustomHeaderValue)
+			if body, ok := req.Body.(zhipuai.ChatCompletionRequest); ok {
+				messages := make([]zhipuai.ChatCompletionMessage, len(body.Messages))
+				copy(messages, body.Messages)
+				for i := range messages {
+					messages[i].Content = redacted
+				}
+				body.Messages = messages
+				if err := req.SetBody(body); err != nil {
+					return nil, err
+				}
+			}
+			return next(req)
+		}
+	}
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.Middlewares = []zhipuai.Middleware{redact}
+	})
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(xCustomHeader) != xCustomHeaderValue {
+			t.Errorf("expected header %s to be set by middleware", xCustomHeader)
+		}
+		req, err := getChatCompletionBody(r)
+		checks.NoError(t, err, "could not read request")
+		if req.Messages[0].Content != redacted {
+			t.Errorf("expected redacted content, got %q", req.Messages[0].Content)
+		}
+		fmt.Fprintln(w, `{"id":"1","object":"chat.completion","choices":[]}`)
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+}
+
+func TestMiddlewareMultipartBody(t *testing.T) {
+	var seen *zhipuai.Request
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.Middlewares = []zhipuai.Middleware{
+			func(next zhipuai.RoundTripFunc) zhipuai.RoundTripFunc {
+				return func(req *zhipuai.Request) (*http.Response, error) {
+					seen = req
+					return next(req)
+				}
+			},
+		}
+	})
+	defer teardown()
+	server.RegisterHandler("/v1/files", handleCreateFile)
+
+	_, err := client.CreateFileBytes(context.Background(), zhipuai.FileBytesRequest{
+		Name:    "foo",
+		Bytes:   []byte("foo"),
+		Purpose: zhipuai.PurposeFineTune,
+	})
+	checks.NoError(t, err, "CreateFileBytes error")
+	if seen.Endpoint != "/files" || seen.Body != nil {
+		t.Errorf("unexpected request info: endpoint %q, body %T", seen.Endpoint, seen.Body)
+	}
+}
@@ -0,0 +1,220 @@
+package zhipuai
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrModelToolsNotSupported  = errors.New("this model does not support tools")
+	ErrModelStreamNotSupported = errors.New("this model does not support streaming")
+	ErrModelMaxTokensExceeded  = errors.New("max_tokens exceeds the output limit of this model")
+	ErrModelParamNotSupported  = errors.New("this model does not accept the parameter")
+	ErrModelParamOutOfRange    = errors.New("the parameter is out of the range accepted by this model")
+)
+
+const (
+	minUserIDLength = 6
+	maxUserIDLength = 128
+)
+
+// ModelCapabilities describes the limits and features of a model.
+type ModelCapabilities struct {
+	Model string
+	// ContextWindow is the maximum number of input and output tokens, 0 if not applicable.
+	ContextWindow int
+	// MaxOutputTokens is the maximum value accepted for max_tokens, 0 if not applicable.
+	MaxOutputTokens int
+	// Endpoints lists the API paths accepting the model, e.g. "/chat/completions".
+	Endpoints         []string
+	SupportsTools     bool
+	SupportsVision    bool
+	SupportsVideo     bool
+	SupportsStreaming bool
+	// ImageSizes lists the sizes accepted by an image generation model, empty if any size is.
+	ImageSizes []string
+	// SupportsCharacterMeta reports whether the model plays the character described by a request Meta.
+	SupportsCharacterMeta bool
+}
+
+// SupportsEndpoint reports whether the model can be used with the given API path.
+func (m ModelCapabilities) SupportsEndpoint(endpoint string) bool {
+	for _, e := range m.Endpoints {
+		if e == endpoint {
+			return true
+		}
+	}
+	return false
+}
+
+// clone returns a copy of m that shares no slice with it, so that the registry
+// cannot be modified through the capabilities it returns or is given.
+func (m ModelCapabilities) clone() ModelCapabilities {
+	m.Endpoints = append([]string(nil), m.Endpoints...)
+	m.ImageSizes = append([]string(nil), m.ImageSizes...)
+	return m
+}
+
+const (
+	glm4ContextWindow   = 128000
+	glm4MaxOutputTokens = 4095
+)
+
+var chatEndpoints = []string{chatCompletionsSuffix}
+
+var modelRegistry = struct {
+	sync.RWMutex
+	models map[string]ModelCapabilities
+}{
+	models: map[string]ModelCapabilities{
+		GLM4: {
+			Model: GLM4, ContextWindow: glm4ContextWindow, MaxOutputTokens: glm4MaxOutputTokens,
+			Endpoints: chatEndpoints, SupportsTools: true, SupportsStreaming: true,
+		},
+		GLM4Plus: {
+			Model: GLM4Plus, ContextWindow: glm4ContextWindow, MaxOutputTokens: glm4MaxOutputTokens,
+			Endpoints: chatEndpoints, SupportsTools: true, SupportsStreaming: true,
+		},
+		GLM4Air: {
+			Model: GLM4Air, ContextWindow: glm4ContextWindow, MaxOutputTokens: glm4MaxOutputTokens,
+			Endpoints: chatEndpoints, SupportsTools: true, SupportsStreaming: true,
+		},
+		GLM4Flash: {
+			Model: GLM4Flash, ContextWindow: glm4ContextWindow, MaxOutputTokens: glm4MaxOutputTokens,
+			Endpoints: chatEndpoints, SupportsTools: true, SupportsStreaming: true,
+		},
+		GLM4Long: {
+			Model: GLM4Long, ContextWindow: 1000000, MaxOutputTokens: glm4MaxOutputTokens,
+			Endpoints: chatEndpoints, SupportsTools: true, SupportsStreaming: true,
+		},
+		GLM4V: {
+			Model: GLM4V, ContextWindow: 2048, MaxOutputTokens: 1024,
+			Endpoints: chatEndpoints, SupportsVision: true, SupportsStreaming: true,
+		},
+		GLM4VPlus: {
+			Model: GLM4VPlus, ContextWindow: 8192, MaxOutputTokens: 1024,
+			Endpoints: chatEndpoints, SupportsVision: true, SupportsVideo: true, SupportsStreaming: true,
+		},
+		CharGLM3: {
+			Model: CharGLM3, ContextWindow: 4096, MaxOutputTokens: 2048,
+			Endpoints: chatEndpoints, SupportsStreaming: true, SupportsCharacterMeta: true,
+		},
+		CodeGeeX4: {
+			Model: CodeGeeX4, ContextWindow: glm4ContextWindow, MaxOutputTokens: 32768,
+			Endpoints: chatEndpoints, SupportsStreaming: true,
+		},
+		CreateImageModelCogView3: {
+			Model: CreateImageModelCogView3, Endpoints: []string{imageGenerationsSuffix},
+			ImageSizes: []string{CreateImageSize1024x1024},
+		},
+		CreateImageModelCogView3Plus: {
+			Model: CreateImageModelCogView3Plus, Endpoints: []string{imageGenerationsSuffix},
+			ImageSizes: []string{
+				CreateImageSize1024x1024, CreateImageSize768x1344, CreateImageSize864x1152, CreateImageSize1344x768,
+				CreateImageSize1152x864, CreateImageSize1440x720, CreateImageSize720x1440,
+			},
+		},
+		string(Embedding2): {
+			Model: string(Embedding2), ContextWindow: 512, Endpoints: []string{embeddingsSuffix},
+		},
+		string(Embedding3): {
+			Model: string(Embedding3), ContextWindow: 8192, Endpoints: []string{embeddingsSuffix},
+		},
+	},
+}
+
+// LookupModel returns the capabilities of a known model.
+func LookupModel(model string) (ModelCapabilities, bool) {
+	modelRegistry.RLock()
+	defer modelRegistry.RUnlock()
+	capabilities, ok := modelRegistry.models[model]
+	return capabilities.clone(), ok
+}
+
+// RegisterModel adds or replaces the capabilities of a model, e.g. a fine-tuned
+// model or one released after this version of the library.
+func RegisterModel(capabilities ModelCapabilities) {
+	modelRegistry.Lock()
+	defer modelRegistry.Unlock()
+	modelRegistry.models[capabilities.Model] = capabilities.clone()
+}
+
+// ListModelCapabilities returns the capabilities of every known model, sorted by name.
+func ListModelCapabilities() []ModelCapabilities {
+	modelRegistry.RLock()
+	defer modelRegistry.RUnlock()
+	models := make([]ModelCapabilities, 0, len(modelRegistry.models))
+	for _, capabilities := range modelRegistry.models {
+		models = append(models, capabilities.clone())
+	}
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].Model < models[j].Model
+	})
+	return models
+}
+
+// validateChatCompletionRequest checks a chat request against the capabilities
+// of its model, the definitions of its tools and its character settings.
+// Capabilities of unknown models are not validated.
+func validateChatCompletionRequest(request ChatCompletionRequest) error {
+	if err := validateTools(request.Tools); err != nil {
+		return err
+	}
+	if err := validateCharacterMeta(request); err != nil {
+		return err
+	}
+	capabilities, ok := LookupModel(request.Model)
+	if !ok {
+		return nil
+	}
+	if request.Stream && !capabilities.SupportsStreaming {
+		return ErrModelStreamNotSupported
+	}
+	if (len(request.Tools) > 0 || len(request.Functions) > 0) && !capabilities.SupportsTools {
+		return ErrModelToolsNotSupported
+	}
+	if err := validateMessageParts(request.Messages, capabilities); err != nil {
+		return err
+	}
+	if capabilities.MaxOutputTokens > 0 && request.MaxTokens > capabilities.MaxOutputTokens {
+		return fmt.Errorf("%w: %d > %d", ErrModelMaxTokensExceeded, request.MaxTokens, capabilities.MaxOutputTokens)
+	}
+	return validateGLMParams(request)
+}
+
+// validateGLMParams rejects the parameters that the chat models of the
+// platform ignore or do not accept.
+func validateGLMParams(request ChatCompletionRequest) error {
+	unsupported := []struct {
+		name string
+		set  bool
+	}{
+		{"logit_bias", len(request.LogitBias) > 0},
+		{"presence_penalty", request.PresencePenalty != 0},
+		{"frequency_penalty", request.FrequencyPenalty != 0},
+		{"n", request.N > 1},
+		{"seed", request.Seed != nil},
+		{"logprobs", request.LogProbs || request.TopLogProbs > 0},
+		{"tool_choice", request.ToolChoice != nil && request.ToolChoice != ToolChoiceAuto},
+	}
+	for _, param := range unsupported {
+		if param.set {
+			return fmt.Errorf("%w: %s", ErrModelParamNotSupported, param.name)
+		}
+	}
+
+	// A zero temperature or top_p is not sent, leaving the default of the model.
+	if request.Temperature < 0 || request.Temperature > 1 {
+		return fmt.Errorf("%w: temperature %v is not in [0, 1]", ErrModelParamOutOfRange, request.Temperature)
+	}
+	if request.TopP < 0 || request.TopP >= 1 {
+		return fmt.Errorf("%w: top_p %v is not in [0, 1)", ErrModelParamOutOfRange, request.TopP)
+	}
+	if n := len(request.UserID); n > 0 && (n < minUserIDLength || n > maxUserIDLength) {
+		return fmt.Errorf("%w: user_id must be %d to %d characters long", ErrModelParamOutOfRange,
+			minUserIDLength, maxUserIDLength)
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package zhipuai_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+func newOfflineClient() *zhipuai.Client {
+	config := zhipuai.DefaultConfig("whatever")
+	config.BaseURL = "http://localhost/v1"
+	return zhipuai.NewClientWithConfig(config)
+}
+
+func TestLookupModel(t *testing.T) {
+	capabilities, ok := zhipuai.LookupModel(zhipuai.GLM4V)
+	if !ok {
+		t.Fatalf("expected %s to be registered", zhipuai.GLM4V)
+	}
+	if !capabilities.SupportsVision || capabilities.SupportsTools {
+		t.Errorf("unexpected capabilities for %s: %+v", zhipuai.GLM4V, capabilities)
+	}
+	if !capabilities.SupportsEndpoint("/chat/completions") || capabilities.SupportsEndpoint("/embeddings") {
+		t.Errorf("unexpected endpoints for %s: %v", zhipuai.GLM4V, capabilities.Endpoints)
+	}
+
+	if _, ok = zhipuai.LookupModel("not-a-model"); ok {
+		t.Errorf("unknown model should not be found")
+	}
+}
+
+func TestLookupModelReturnsCopies(t *testing.T) {
+	capabilities, _ := zhipuai.LookupModel(zhipuai.GLM4)
+	capabilities.Endpoints[0] = "/embeddings"
+	for _, m := range zhipuai.ListModelCapabilities() {
+		if m.Model == zhipuai.CreateImageModelCogView3Plus {
+			m.ImageSizes[0] = "1x1"
+		}
+	}
+
+	if glm4V, _ := zhipuai.LookupModel(zhipuai.GLM4V); !glm4V.SupportsEndpoint("/chat/completions") {
+		t.Errorf("modifying returned endpoints changed the registry: %v", glm4V.Endpoints)
+	}
+	if cogView, _ := zhipuai.LookupModel(zhipuai.CreateImageModelCogView3Plus); cogView.ImageSizes[0] == "1x1" {
+		t.Errorf("modifying returned image sizes changed the registry: %v", cogView.ImageSizes)
+	}
+}
+
+func TestRegisterModel(t *testing.T) {
+	const fineTuned = "glm-4-flash:ft:test"
+	zhipuai.RegisterModel(zhipuai.ModelCapabilities{
+		Model:             fineTuned,
+		MaxOutputTokens:   10,
+		Endpoints:         []string{"/chat/completions"},
+		SupportsStreaming: true,
+	})
+
+	models := zhipuai.ListModelCapabilities()
+	found := false
+	for i, m := range models {
+		if i > 0 && models[i-1].Model > m.Model {
+			t.Errorf("models are not sorted: %s before %s", models[i-1].Model, m.Model)
+		}
+		found = found || m.Model == fineTuned
+	}
+	if !found {
+		t.Errorf("registered model %s is not listed", fineTuned)
+	}
+
+	req := testChatCompletionRequest
+	req.Model = fineTuned
+	req.MaxTokens = 11
+	_, err := newOfflineClient().CreateChatCompletion(context.Background(), req)
+	checks.ErrorIs(t, err, zhipuai.ErrModelMaxTokensExceeded, "registered limits should be enforced")
+}
+
+func TestChatCompletionModelValidation(t *testing.T) {
+	client := newOfflineClient()
+	ctx := context.Background()
+
+	req := testChatCompletionRequest
+	req.Model = string(zhipuai.Embedding3)
+	_, err := client.CreateChatCompletion(ctx, req)
+	checks.ErrorIs(t, err, zhipuai.ErrChatCompletionInvalidModel, "embedding model should be rejected")
+
+	req = testChatCompletionRequest
+	req.Model = zhipuai.GLM4V
+	req.Tools = []zhipuai.Tool{{Type: zhipuai.ToolTypeFunction, Function: &zhipuai.FunctionDefinition{Name: "f"}}}
+	_, err = client.CreateChatCompletion(ctx, req)
+	checks.ErrorIs(t, err, zhipuai.ErrModelToolsNotSupported, "tools should be rejected for glm-4v")
+
+	req = testChatCompletionRequest
+	req.Model = zhipuai.GLM4
+	req.MaxTokens = 100000
+	_, err = client.CreateChatCompletionStream(ctx, req)
+	checks.ErrorIs(t, err, zhipuai.ErrModelMaxTokensExceeded, "max_tokens above the model limit should be rejected")
+}
+
+func TestChatCompletionGLMParamsValidation(t *testing.T) {
+	seed := 1
+	tests := []struct {
+		name   string
+		modify func(*zhipuai.ChatCompletionRequest)
+		want   error
+	}{
+		{"logit_bias", func(r *zhipuai.ChatCompletionRequest) { r.LogitBias = map[string]int{"1": 1} },
+			zhipuai.ErrModelParamNotSupported},
+		{"n", func(r *zhipuai.ChatCompletionRequest) { r.N = 2 }, zhipuai.ErrModelParamNotSupported},
+		{"seed", func(r *zhipuai.ChatCompletionRequest) { r.Seed = &seed }, zhipuai.ErrModelParamNotSupported},
+		{"tool_choice", func(r *zhipuai.ChatCompletionRequest) { r.ToolChoice = "none" },
+			zhipuai.ErrModelParamNotSupported},
+		{"temperature", func(r *zhipuai.ChatCompletionRequest) { r.Temperature = 1.5 }, zhipuai.ErrModelParamOutOfRange},
+		{"top_p", func(r *zhipuai.ChatCompletionRequest) { r.TopP = 1 }, zhipuai.ErrModelParamOutOfRange},
+		{"user_id", func(r *zhipuai.ChatCompletionRequest) { r.UserID = "u1" }, zhipuai.ErrModelParamOutOfRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := testChatCompletionRequest
+			req.Model = zhipuai.GLM4
+			tt.modify(&req)
+			_, err := newOfflineClient().CreateChatCompletion(context.Background(), req)
+			checks.ErrorIs(t, err, tt.want, "invalid parameters should be rejected")
+		})
+	}
+}
+
+func TestChatCompletionSamplingWithDefaultTemperature(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", handleChatCompletionEndpoint)
+
+	doSample := true
+	req := testChatCompletionRequest
+	req.Model = zhipuai.GLM4
+	req.DoSample = &doSample
+	_, err := client.CreateChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "sampling without a temperature should use the default temperature")
+}
+
+func TestEmbeddingAndImageModelValidation(t *testing.T) {
+	client := newOfflineClient()
+	ctx := context.Background()
+
+	_, err := client.CreateEmbeddings(ctx, zhipuai.EmbeddingRequestStrings{
+		Input: []string{"hello"},
+		Model: zhipuai.GLM4,
+	})
+	checks.ErrorIs(t, err, zhipuai.ErrEmbeddingInvalidModel, "chat model should be rejected for embeddings")
+
+	_, err = client.CreateImage(ctx, zhipuai.ImageRequest{
+		Prompt: "a cat",
+		Model:  zhipuai.GLM4,
+	})
+	checks.ErrorIs(t, err, zhipuai.ErrImageInvalidModel, "chat model should be rejected for images")
+}
@@ -7,7 +7,7 @@ import (
 
 func setupzhipuaiTestServer() (client *zhipuai.Client, server *test.ServerTest, teardown func()) {
 	server = test.NewTestServer()
-	ts := server.zhipuaiTestServer()
+	ts := server.ZhipuaiTestServer()
 	ts.Start()
 	teardown = ts.Close
 	config := zhipuai.DefaultConfig(test.GetTestToken())
@@ -16,9 +16,27 @@ func setupzhipuaiTestServer() (client *zhipuai.Client, server *test.ServerTest,
 	return
 }
 
+// setupzhipuaiTestServerWithConfig is like setupzhipuaiTestServer, but lets the
+// caller adjust the client config before the client is created.
+func setupzhipuaiTestServerWithConfig(configure func(*zhipuai.ClientConfig)) (
+	client *zhipuai.Client,
+	server *test.ServerTest,
+	teardown func(),
+) {
+	server = test.NewTestServer()
+	ts := server.ZhipuaiTestServer()
+	ts.Start()
+	teardown = ts.Close
+	config := zhipuai.DefaultConfig(test.GetTestToken())
+	config.BaseURL = ts.URL + "/v1"
+	configure(&config)
+	client = zhipuai.NewClientWithConfig(config)
+	return
+}
+
 func setupAzureTestServer() (client *zhipuai.Client, server *test.ServerTest, teardown func()) {
 	server = test.NewTestServer()
-	ts := server.zhipuaiTestServer()
+	ts := server.ZhipuaiTestServer()
 	ts.Start()
 	teardown = ts.Close
 	config := zhipuai.DefaultAzureConfig(test.GetTestToken(), "https://dummylab.zhipuai.azure.com/")
@@ -27,6 +27,11 @@ func (r ResetTime) Time() time.Time {
 	return time.Now().Add(d)
 }
 
+func (r ResetTime) duration() (time.Duration, bool) {
+	d, err := time.ParseDuration(string(r))
+	return d, err == nil
+}
+
 func newRateLimitHeaders(h http.Header) RateLimitHeaders {
 	limitReq, _ := strconv.Atoi(h.Get("x-ratelimit-limit-requests"))
 	limitTokens, _ := strconv.Atoi(h.Get("x-ratelimit-limit-tokens"))
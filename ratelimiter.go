@@ -0,0 +1,276 @@
+package zhipuai
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// charsPerToken is the rule of thumb used to estimate prompt tokens before the
+// server reports the actual usage.
+const charsPerToken = 4
+
+// RateLimit configures the client-side rate limiter. Requests block before they
+// are sent until both budgets allow them, or until their context is done.
+// A zero budget is not limited; the zero value disables the limiter.
+type RateLimit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+func (r RateLimit) enabled() bool {
+	return r.RequestsPerMinute > 0 || r.TokensPerMinute > 0
+}
+
+// tokenBucket refills continuously up to its capacity over one minute.
+type tokenBucket struct {
+	capacity    float64
+	available   float64
+	lastRefill  time.Time
+	pausedUntil time.Time
+}
+
+func newTokenBucket(perMinute int, now time.Time) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		capacity:   float64(perMinute),
+		available:  float64(perMinute),
+		lastRefill: now,
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.lastRefill)
+	if elapsed <= 0 {
+		return
+	}
+	b.available += b.capacity * elapsed.Minutes()
+	if b.available > b.capacity {
+		b.available = b.capacity
+	}
+	b.lastRefill = now
+}
+
+// delay returns how long to wait until n units are available.
+func (b *tokenBucket) delay(n float64, now time.Time) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.refill(now)
+	if n > b.capacity {
+		n = b.capacity
+	}
+	var wait time.Duration
+	if now.Before(b.pausedUntil) {
+		wait = b.pausedUntil.Sub(now)
+	}
+	if missing := n - b.available; missing > 0 {
+		if d := time.Duration(missing / b.capacity * float64(time.Minute)); d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+func (b *tokenBucket) take(n float64) {
+	if b == nil {
+		return
+	}
+	if n > b.capacity {
+		n = b.capacity
+	}
+	b.available -= n
+}
+
+func (b *tokenBucket) give(n float64) {
+	if b == nil {
+		return
+	}
+	b.available += n
+	if b.available > b.capacity {
+		b.available = b.capacity
+	}
+}
+
+// correct lowers the budget to what the server reports as remaining, and pauses
+// the bucket until the reported reset when the budget is exhausted.
+func (b *tokenBucket) correct(limit, remaining int, reset ResetTime, hasRemaining bool, now time.Time) {
+	if b == nil {
+		return
+	}
+	if limit > 0 && float64(limit) < b.capacity {
+		b.capacity = float64(limit)
+	}
+	if !hasRemaining {
+		return
+	}
+	b.refill(now)
+	if float64(remaining) < b.available {
+		b.available = float64(remaining)
+	}
+	if d, ok := reset.duration(); ok && remaining <= 0 {
+		b.pausedUntil = now.Add(d)
+	}
+}
+
+// rateLimiter tracks the requests-per-minute and tokens-per-minute budgets of a client.
+type rateLimiter struct {
+	mu       sync.Mutex
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if !limit.enabled() {
+		return nil
+	}
+	now := time.Now()
+	return &rateLimiter{
+		requests: newTokenBucket(limit.RequestsPerMinute, now),
+		tokens:   newTokenBucket(limit.TokensPerMinute, now),
+	}
+}
+
+// wait blocks until one request and the given number of tokens fit in the budgets,
+// then reserves them.
+func (l *rateLimiter) wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		delay := l.requests.delay(1, now)
+		if d := l.tokens.delay(float64(tokens), now); d > delay {
+			delay = d
+		}
+		if delay <= 0 {
+			l.requests.take(1)
+			l.tokens.take(float64(tokens))
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// refund returns the tokens reserved for an attempt that is retried, so that a
+// request holds a single reservation however many times it is sent.
+func (l *rateLimiter) refund(tokens int) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens.give(float64(tokens))
+}
+
+// update corrects the budgets from the x-ratelimit-* response headers.
+func (l *rateLimiter) update(header http.Header) {
+	if l == nil {
+		return
+	}
+	limits := newRateLimitHeaders(header)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.requests.correct(limits.LimitRequests, limits.RemainingRequests, limits.ResetRequests,
+		header.Get("x-ratelimit-remaining-requests") != "", now)
+	l.tokens.correct(limits.LimitTokens, limits.RemainingTokens, limits.ResetTokens,
+		header.Get("x-ratelimit-remaining-tokens") != "", now)
+}
+
+// settle replaces the estimated token cost of a request with the usage reported
+// in its response, if any.
+func (l *rateLimiter) settle(estimated int, v any) {
+	if usage, ok := responseUsage(v); ok {
+		l.settleUsage(estimated, usage)
+	}
+}
+
+// settleUsage corrects the token bucket with the usage reported for a request.
+func (l *rateLimiter) settleUsage(estimated int, usage Usage) {
+	if l == nil || usage.TotalTokens == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if diff := estimated - usage.TotalTokens; diff > 0 {
+		l.tokens.give(float64(diff))
+	} else {
+		l.tokens.take(float64(-diff))
+	}
+}
+
+func responseUsage(v any) (Usage, bool) {
+	switch o := v.(type) {
+	case *ChatCompletionResponse:
+		return o.Usage, true
+	case *CompletionResponse:
+		return o.Usage, true
+	case *EmbeddingResponse:
+		return o.Usage, true
+	case *EmbeddingResponseBase64:
+		return o.Usage, true
+	default:
+		return Usage{}, false
+	}
+}
+
+// estimateTokens roughly estimates the tokens a request will consume from its
+// prompt size and requested completion length.
+func estimateTokens(req *http.Request) int {
+	info, _ := req.Context().Value(requestInfoKey{}).(requestInfo)
+	switch body := info.body.(type) {
+	case ChatCompletionRequest:
+		chars := 0
+		for _, message := range body.Messages {
+			chars += len(message.Content)
+			for _, part := range message.MultiContent {
+				chars += len(part.Text)
+			}
+		}
+		return chars/charsPerToken + body.MaxTokens
+	case CompletionRequest:
+		chars := 0
+		switch prompt := body.Prompt.(type) {
+		case string:
+			chars = len(prompt)
+		case []string:
+			for _, p := range prompt {
+				chars += len(p)
+			}
+		}
+		return chars/charsPerToken + body.MaxTokens
+	case EmbeddingRequest:
+		switch input := body.Input.(type) {
+		case string:
+			return len(input) / charsPerToken
+		case []string:
+			chars := 0
+			for _, in := range input {
+				chars += len(in)
+			}
+			return chars / charsPerToken
+		case [][]int:
+			tokens := 0
+			for _, in := range input {
+				tokens += len(in)
+			}
+			return tokens
+		}
+		return 0
+	default:
+		return 0
+	}
+}
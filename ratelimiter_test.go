@@ -0,0 +1,125 @@
+package zhipuai_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+func setupRateLimitedTestServer(limit zhipuai.RateLimit) (
+	client *zhipuai.Client,
+	server *test.ServerTest,
+	teardown func(),
+) {
+	return setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.RateLimit = limit
+	})
+}
+
+func TestRateLimiterBlocksOnRequestBudget(t *testing.T) {
+	client, server, teardown := setupRateLimitedTestServer(zhipuai.RateLimit{RequestsPerMinute: 1})
+	defer teardown()
+
+	attempts := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		handleChatCompletionEndpoint(w, r)
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.CreateChatCompletion(ctx, testChatCompletionRequest)
+	checks.ErrorIs(t, err, context.DeadlineExceeded, "second request should wait for the request budget")
+	if attempts != 1 {
+		t.Errorf("expected 1 request to reach the server, got %d", attempts)
+	}
+}
+
+func TestRateLimiterBlocksOnTokenBudget(t *testing.T) {
+	client, server, teardown := setupRateLimitedTestServer(zhipuai.RateLimit{TokensPerMinute: 100})
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"id":"1","object":"chat.completion","choices":[]}`)
+	})
+
+	req := testChatCompletionRequest
+	req.MaxTokens = 200
+	_, err := client.CreateChatCompletion(context.Background(), req)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.CreateChatCompletion(ctx, req)
+	checks.ErrorIs(t, err, context.DeadlineExceeded, "second request should wait for the token budget")
+}
+
+func TestRateLimiterRefundsFromUsage(t *testing.T) {
+	client, server, teardown := setupRateLimitedTestServer(zhipuai.RateLimit{TokensPerMinute: 1000})
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, `{"id":"1","object":"chat.completion","choices":[],"usage":{"total_tokens":10}}`)
+	})
+
+	req := testChatCompletionRequest
+	req.MaxTokens = 900
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := client.CreateChatCompletion(ctx, req)
+		cancel()
+		checks.NoError(t, err, "reported usage should return unused tokens to the budget")
+	}
+}
+
+func TestRateLimiterReservesOncePerRequest(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.RateLimit = zhipuai.RateLimit{TokensPerMinute: 1000}
+		config.RetryPolicy = testRetryPolicy()
+	})
+	defer teardown()
+	attempts := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, `{"id":"1","object":"chat.completion","choices":[],"usage":{"total_tokens":10}}`)
+	})
+
+	req := testChatCompletionRequest
+	req.MaxTokens = 450
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err := client.CreateChatCompletion(ctx, req)
+	checks.NoError(t, err, "retried attempts should not reserve the estimated tokens again")
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRateLimiterCorrectsFromHeaders(t *testing.T) {
+	client, server, teardown := setupRateLimitedTestServer(zhipuai.RateLimit{RequestsPerMinute: 100})
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("x-ratelimit-remaining-requests", "0")
+		w.Header().Set("x-ratelimit-reset-requests", "1m")
+		fmt.Fprintln(w, `{"id":"1","object":"chat.completion","choices":[]}`)
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.CreateChatCompletion(ctx, testChatCompletionRequest)
+	checks.ErrorIs(t, err, context.DeadlineExceeded, "exhausted server budget should block until reset")
+}
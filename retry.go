@@ -0,0 +1,290 @@
+package zhipuai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts    = 3
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff     = 10 * time.Second
+	defaultRetryMultiplier     = 2
+	defaultRetryJitter         = 0.2
+)
+
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// RetryPolicy describes how the client replays requests that failed with a
+// transient error: a transport error or one of RetryableStatusCodes.
+// Only requests whose body can be rebuilt (see http.Request.GetBody) are replayed,
+// which covers every JSON and multipart request built by this client.
+//
+// The zero value disables retries.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the exponential delay. It does not apply to delays
+	// requested by the server through Retry-After or x-ratelimit-reset-* headers.
+	MaxBackoff time.Duration
+	// Multiplier is the factor the delay grows by after every retry.
+	Multiplier float64
+	// Jitter is the fraction of the delay, between 0 and 1, that is randomized.
+	Jitter float64
+	// RetryableStatusCodes lists the HTTP status codes that are retried.
+	// When empty, 429 and 500, 502, 503, 504 are retried.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy returns a policy with 3 attempts and exponential backoff
+// starting at 500ms.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    defaultRetryMaxAttempts,
+		InitialBackoff: defaultRetryInitialBackoff,
+		MaxBackoff:     defaultRetryMaxBackoff,
+		Multiplier:     defaultRetryMultiplier,
+		Jitter:         defaultRetryJitter,
+	}
+}
+
+func (p RetryPolicy) enabled() bool {
+	return p.MaxAttempts > 1
+}
+
+func (p RetryPolicy) isRetryableStatus(code int) bool {
+	codes := p.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryableStatusCodes
+	}
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RetryPolicy) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if !canRewind(req) {
+		return false
+	}
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	return p.isRetryableStatus(resp.StatusCode)
+}
+
+// backoff returns the delay before the given retry attempt (1-based). A delay
+// requested by the server takes precedence over the exponential backoff.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := serverRetryDelay(resp); ok {
+			return d
+		}
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * math.Min(p.Jitter, 1) * rand.Float64() //nolint:gosec // jitter does not need crypto rand
+	}
+	return time.Duration(delay)
+}
+
+// serverRetryDelay reads the delay the server asked for, from Retry-After or,
+// for exhausted rate limits, from the x-ratelimit-reset-* headers.
+func serverRetryDelay(resp *http.Response) (time.Duration, bool) {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return time.Until(at), true
+		}
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	var (
+		delay time.Duration
+		found bool
+	)
+	limits := newRateLimitHeaders(resp.Header)
+	if d, ok := limits.ResetRequests.duration(); ok && limits.RemainingRequests == 0 {
+		delay, found = d, true
+	}
+	if d, ok := limits.ResetTokens.duration(); ok && limits.RemainingTokens == 0 && d > delay {
+		delay, found = d, true
+	}
+	return delay, found
+}
+
+// doRequest sends req through the client's rate limiter, middlewares and HTTP
+// client, replaying it according to the RetryPolicy. When waitFirstEvent is set,
+// a successful response whose body fails before yielding its first data line is
+// retried as well; this is used by streaming calls so that a connection dropped
+// before the first event is re-issued.
+func (c *Client) doRequest(req *http.Request, waitFirstEvent bool) (*http.Response, error) {
+	policy := c.config.RetryPolicy
+	tokens := estimateTokens(req)
+	attempt, failovers := 1, 0
+	for {
+		lastAttempt := !policy.enabled() || attempt >= policy.MaxAttempts
+
+		if err := c.limiter.wait(req.Context(), tokens); err != nil {
+			return nil, err
+		}
+		resp, err := c.roundTrip(req)
+		if resp != nil {
+			c.limiter.update(resp.Header)
+		}
+		failover := c.observeResponse(req, resp) && failovers < maxCredentialFailovers && canRewind(req)
+		if err == nil && waitFirstEvent && !lastAttempt && canRewind(req) && !isFailureStatusCode(resp) {
+			err = peekFirstEvent(resp)
+			if err != nil {
+				resp = nil
+			}
+		}
+
+		var delay time.Duration
+		switch {
+		case failover:
+			// another credential is available, re-send right away
+			failovers++
+		case lastAttempt || !policy.shouldRetry(req, resp, err):
+			return resp, err
+		default:
+			delay = policy.backoff(attempt, resp)
+			attempt++
+		}
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		c.limiter.refund(tokens)
+
+		req, err = c.rewindRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		if err = sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// maxStreamPeekSize bounds how much of a stream is buffered while waiting for
+// its first data line.
+const maxStreamPeekSize = 64 << 10
+
+type bufferedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// peekFirstEvent blocks until the response body yields its first data line, so
+// that a stream dropped after only comments, such as heartbeats, or other
+// fields is retried too. A body without a data line in its first 64 KiB is
+// left to the stream reader. On failure the body is closed.
+func peekFirstEvent(resp *http.Response) error {
+	var buffered []byte
+	chunk := make([]byte, 4096)
+	for len(buffered) < maxStreamPeekSize && !hasDataLine(buffered, false) {
+		n, err := resp.Body.Read(chunk)
+		buffered = append(buffered, chunk[:n]...)
+		if err == nil {
+			continue
+		}
+		if errors.Is(err, io.EOF) && hasDataLine(buffered, true) {
+			break
+		}
+		resp.Body.Close()
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	resp.Body = bufferedReadCloser{Reader: io.MultiReader(bytes.NewReader(buffered), resp.Body), Closer: resp.Body}
+	return nil
+}
+
+// hasDataLine reports whether buf holds a complete event stream line with data.
+// At the end of the stream, the last line needs no terminator.
+func hasDataLine(buf []byte, atEOF bool) bool {
+	for len(buf) > 0 {
+		end := bytes.IndexAny(buf, "\r\n")
+		if end < 0 && !atEOF {
+			return false
+		}
+		line := buf
+		if end >= 0 {
+			line, buf = buf[:end], buf[end+1:]
+		} else {
+			buf = nil
+		}
+		if value := bytes.TrimPrefix(line, []byte("data:")); len(value) < len(line) &&
+			len(bytes.TrimPrefix(value, []byte(" "))) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// rewindRequest prepares a copy of req to be sent again, with a fresh body and
+// fresh credentials.
+func (c *Client) rewindRequest(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	}
+	if err := c.setCommonHeaders(next); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
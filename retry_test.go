@@ -0,0 +1,187 @@
+package zhipuai_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+func setupRetryTestServer(policy zhipuai.RetryPolicy) (
+	client *zhipuai.Client,
+	server *test.ServerTest,
+	teardown func(),
+) {
+	return setupzhipuaiTestServerWithConfig(func(config *zhipuai.ClientConfig) {
+		config.RetryPolicy = policy
+	})
+}
+
+func testRetryPolicy() zhipuai.RetryPolicy {
+	return zhipuai.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     5 * time.Millisecond,
+		Multiplier:     2,
+	}
+}
+
+var testChatCompletionRequest = zhipuai.ChatCompletionRequest{
+	MaxTokens: 5,
+	Model:     zhipuai.GPT3Dot5Turbo,
+	Messages: []zhipuai.ChatCompletionMessage{
+		{
+			Role:    zhipuai.ChatMessageRoleUser,
+			Content: "Hello!",
+		},
+	},
+}
+
+func TestRetryOnRateLimit(t *testing.T) {
+	client, server, teardown := setupRetryTestServer(testRetryPolicy())
+	defer teardown()
+
+	attempts := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprintln(w, `{"error":{"message":"rate limited","code":"1302"}}`)
+			return
+		}
+		handleChatCompletionEndpoint(w, r)
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateChatCompletion error")
+	if attempts != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	client, server, teardown := setupRetryTestServer(testRetryPolicy())
+	defer teardown()
+
+	attempts := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, `{"error":{"message":"overloaded","code":"1305"}}`)
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+	var apiErr *zhipuai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected APIError with status 503, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetrySkipsNonRetryableStatus(t *testing.T) {
+	client, server, teardown := setupRetryTestServer(testRetryPolicy())
+	defer teardown()
+
+	attempts := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintln(w, `{"error":{"message":"bad request","code":"1210"}}`)
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+	checks.HasError(t, err, "CreateChatCompletion should fail")
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryDisabledByDefault(t *testing.T) {
+	client, server, teardown := setupRetryTestServer(zhipuai.RetryPolicy{})
+	defer teardown()
+
+	attempts := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), testChatCompletionRequest)
+	checks.HasError(t, err, "CreateChatCompletion should fail")
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestRetryHonorsContextDuringBackoff(t *testing.T) {
+	policy := testRetryPolicy()
+	policy.InitialBackoff = time.Minute
+	policy.MaxBackoff = time.Minute
+	client, server, teardown := setupRetryTestServer(policy)
+	defer teardown()
+
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.CreateChatCompletion(ctx, testChatCompletionRequest)
+	checks.ErrorIs(t, err, context.DeadlineExceeded, "CreateChatCompletion should stop waiting on context deadline")
+}
+
+func TestRetryStreamBeforeFirstEvent(t *testing.T) {
+	policy := testRetryPolicy()
+	policy.MaxAttempts = 4
+	client, server, teardown := setupRetryTestServer(policy)
+	defer teardown()
+
+	attempts := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		attempts++
+		switch attempts {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			// connection established, but dropped before any event is sent
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+		case 3:
+			// only a heartbeat and an event name are sent before the connection drops
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, ": ping\n\nevent: message\n")
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		default:
+			w.Header().Set("Content-Type", "text/event-stream")
+			//nolint:lll
+			fmt.Fprint(w, `data: {"id":"1","object":"completion","created":1598069254,"model":"glm-4","choices":[{"index":0,"delta":{"content":"response1"},"finish_reason":"stop"}]}`+"\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+		}
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateChatCompletionStream returned error")
+	defer stream.Close()
+
+	resp, err := stream.Recv()
+	checks.NoError(t, err, "stream.Recv() returned error")
+	if resp.Choices[0].Delta.Content != "response1" {
+		t.Errorf("unexpected stream content: %q", resp.Choices[0].Delta.Content)
+	}
+	_, err = stream.Recv()
+	checks.ErrorIs(t, err, io.EOF, "stream should be finished")
+	if attempts != 4 {
+		t.Errorf("expected 4 attempts, got %d", attempts)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 var (
 	ErrTooManyEmptyStreamMessages = errors.New("stream has sent too many empty messages")
+	ErrStreamIdle                 = errors.New("stream has not sent any message within the idle timeout")
 )
 
 type CompletionStream struct {
@@ -0,0 +1,130 @@
+package zhipuai
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// StreamEvent is a chunk received from a stream, or the error that ended it.
+type StreamEvent[T streamable] struct {
+	Response T
+	Err      error
+}
+
+// Events reads the stream in a goroutine and delivers its chunks on the
+// returned channel. A failed read is delivered as a last event holding the
+// error; the channel is closed when the stream ends.
+//
+// The goroutine exits and the channel is closed without a further event when
+// ctx is done or the stream is closed; on ctx cancellation the stream is closed
+// to unblock a pending read.
+func (stream *streamReader[T]) Events(ctx context.Context) <-chan StreamEvent[T] {
+	events := make(chan StreamEvent[T])
+	finished := make(chan struct{})
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			stream.Close()
+		case <-finished:
+		}
+	}()
+
+	go func() {
+		defer close(events)
+		defer close(finished)
+		for {
+			response, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil && (ctx.Err() != nil || isClosed(stream.closed())) {
+				return
+			}
+
+			select {
+			case events <- StreamEvent[T]{Response: response, Err: err}:
+			case <-ctx.Done():
+				return
+			case <-stream.closed():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return events
+}
+
+func isClosed(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// ChatCompletionStreamHandler holds the callbacks invoked by ChatCompletionStream.Run.
+// Every callback is optional.
+type ChatCompletionStreamHandler struct {
+	// OnDelta is called for every choice delta received.
+	OnDelta func(choiceIndex int, delta ChatCompletionStreamChoiceDelta)
+	// OnToolCall is called with each complete tool call once its choice has finished.
+	OnToolCall func(choiceIndex int, toolCall ToolCall)
+	// OnFinish is called with the accumulated response when the stream ends.
+	OnFinish func(response ChatCompletionResponse)
+	// OnError is called when the stream fails or ctx is done.
+	OnError func(err error)
+}
+
+// Run reads the stream and dispatches its chunks to the handler until the
+// stream ends, fails or ctx is done. It returns the error passed to OnError.
+// If the stream is closed while running, Run returns nil without calling OnFinish.
+func (stream *ChatCompletionStream) Run(ctx context.Context, handler ChatCompletionStreamHandler) error {
+	var accumulator ChatCompletionAccumulator
+	for event := range stream.Events(ctx) {
+		if event.Err != nil {
+			return handler.fail(event.Err)
+		}
+
+		if err := accumulator.Add(event.Response); err != nil {
+			stream.Close()
+			return handler.fail(err)
+		}
+		for _, choice := range event.Response.Choices {
+			if handler.OnDelta != nil {
+				handler.OnDelta(choice.Index, choice.Delta)
+			}
+			if choice.FinishReason == "" || handler.OnToolCall == nil {
+				continue
+			}
+			for _, toolCall := range accumulator.Response().Choices[choice.Index].Message.ToolCalls {
+				handler.OnToolCall(choice.Index, toolCall)
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return handler.fail(err)
+	}
+	if isClosed(stream.closed()) && !stream.isFinished {
+		return nil
+	}
+	if handler.OnFinish != nil {
+		response := accumulator.Response()
+		response.SetHeader(stream.Header())
+		handler.OnFinish(response)
+	}
+	return nil
+}
+
+func (h ChatCompletionStreamHandler) fail(err error) error {
+	if h.OnError != nil {
+		h.OnError(err)
+	}
+	return err
+}
@@ -0,0 +1,168 @@
+package zhipuai_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+)
+
+//nolint:lll
+const testToolCallStream = `data: {"id":"1","model":"glm-4","choices":[{"index":0,"delta":{"role":"assistant","content":"Let me check"}}]}
+
+data: {"id":"1","model":"glm-4","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":"}}]}}]}
+
+data: {"id":"1","model":"glm-4","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Beijing\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"total_tokens":9}}
+
+data: [DONE]
+
+`
+
+func TestChatCompletionStreamEvents(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err := w.Write([]byte(testToolCallStream))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	count := 0
+	for event := range stream.Events(context.Background()) {
+		checks.NoError(t, event.Err, "unexpected stream error")
+		count++
+	}
+	if count != 3 {
+		t.Errorf("expected 3 events, got %d", count)
+	}
+}
+
+func TestChatCompletionStreamEventsError(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err := w.Write([]byte(`data: {"error":{"message":"boom","type":"server_error"}}` + "\n\n"))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	var events []zhipuai.StreamEvent[zhipuai.ChatCompletionStreamResponse]
+	for event := range stream.Events(context.Background()) {
+		events = append(events, event)
+	}
+	var apiErr *zhipuai.APIError
+	if len(events) != 1 || !errors.As(events[0].Err, &apiErr) || apiErr.Message != "boom" {
+		t.Errorf("expected a single error event, got %+v", events)
+	}
+}
+
+func TestChatCompletionStreamEventsCancel(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err := w.Write([]byte(`data: {"id":"1","choices":[{"index":0,"delta":{"content":"a"}}]}` + "\n\n"))
+		checks.NoError(t, err, "Write error")
+		w.(http.Flusher).Flush()
+		// Stall until the client goes away.
+		<-r.Context().Done()
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := stream.Events(ctx)
+	event := <-events
+	checks.NoError(t, event.Err, "unexpected stream error")
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Errorf("expected no event after cancellation")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("events channel was not closed after cancellation")
+	}
+}
+
+func TestChatCompletionStreamRun(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err := w.Write([]byte(testToolCallStream))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	var (
+		text      string
+		toolCalls []zhipuai.ToolCall
+		finished  zhipuai.ChatCompletionResponse
+	)
+	err = stream.Run(context.Background(), zhipuai.ChatCompletionStreamHandler{
+		OnDelta: func(_ int, delta zhipuai.ChatCompletionStreamChoiceDelta) {
+			text += delta.Content
+		},
+		OnToolCall: func(_ int, toolCall zhipuai.ToolCall) {
+			toolCalls = append(toolCalls, toolCall)
+		},
+		OnFinish: func(response zhipuai.ChatCompletionResponse) {
+			finished = response
+		},
+		OnError: func(err error) {
+			t.Errorf("unexpected error: %v", err)
+		},
+	})
+	checks.NoError(t, err, "Run error")
+
+	if text != "Let me check" {
+		t.Errorf("unexpected text: %q", text)
+	}
+	if len(toolCalls) != 1 || toolCalls[0].Function.Arguments != `{"city":"Beijing"}` {
+		t.Errorf("unexpected tool calls: %+v", toolCalls)
+	}
+	if finished.Usage.TotalTokens != 9 || finished.Choices[0].FinishReason != zhipuai.FinishReasonToolCalls {
+		t.Errorf("unexpected final response: %+v", finished)
+	}
+}
+
+func TestChatCompletionStreamRunInvalidChoiceIndex(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, err := w.Write([]byte(`data: {"id":"1","choices":[{"index":-1,"finish_reason":"stop"}]}` + "\n\n"))
+		checks.NoError(t, err, "Write error")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "CreateCompletionStream returned error")
+	defer stream.Close()
+
+	var handled error
+	err = stream.Run(context.Background(), zhipuai.ChatCompletionStreamHandler{
+		OnToolCall: func(int, zhipuai.ToolCall) {},
+		OnError:    func(err error) { handled = err },
+	})
+	checks.ErrorIs(t, err, zhipuai.ErrStreamChoiceIndexInvalid, "invalid index should fail the run")
+	checks.ErrorIs(t, handled, zhipuai.ErrStreamChoiceIndexInvalid, "OnError should receive the error")
+}
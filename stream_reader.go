@@ -3,17 +3,21 @@ package zhipuai
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	utils "github.com/bbang94/go-zhipuai/internal"
 )
 
-var (
-	headerData  = []byte("data: ")
-	errorPrefix = []byte(`data: {"error":`)
-)
+var errorPrefix = []byte(`{"error":`)
 
 type streamable interface {
 	ChatCompletionStreamResponse | CompletionResponse
@@ -24,10 +28,32 @@ type streamReader[T streamable] struct {
 	isFinished         bool
 
 	reader         *bufio.Reader
+	decoder        *utils.SSEDecoder
+	event          string
 	response       *http.Response
 	errAccumulator utils.ErrorAccumulator
 	unmarshaler    utils.Unmarshaler
 
+	doneOnce  sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+	// mu guards response, which is replaced when the stream is resumed.
+	mu sync.Mutex
+
+	idleTimeout time.Duration
+	// idle is set to 1 once the idle timeout has closed the response body.
+	idle int32
+
+	// resume requests the stream again with the text received so far as a prefix.
+	resume      func(prefix string) (*http.Response, error)
+	resumesLeft int
+	received    strings.Builder
+
+	usage        *Usage
+	finishReason FinishReason
+	// onUsage is called with the usage reported by the stream once it is finished.
+	onUsage func(resp *http.Response, usage Usage)
+
 	httpHeader
 }
 
@@ -37,63 +63,206 @@ func (stream *streamReader[T]) Recv() (response T, err error) {
 		return
 	}
 
-	response, err = stream.processLines()
-	return
+	for {
+		response, err = stream.recvWithTimeout()
+		if err == nil || !stream.canResume(err) {
+			return
+		}
+		if resumeErr := stream.resumeStream(); resumeErr != nil {
+			err = fmt.Errorf("%w, resume failed: %v", err, resumeErr) //nolint:errorlint // the first error is the cause
+			return
+		}
+	}
+}
+
+func (stream *streamReader[T]) recvWithTimeout() (T, error) {
+	if stream.idleTimeout <= 0 {
+		return stream.processLines()
+	}
+	if atomic.LoadInt32(&stream.idle) == 1 {
+		return *new(T), ErrStreamIdle
+	}
+
+	body := stream.response.Body
+	timer := time.AfterFunc(stream.idleTimeout, func() {
+		atomic.StoreInt32(&stream.idle, 1)
+		body.Close()
+	})
+	response, err := stream.processLines()
+	if !timer.Stop() && err != nil && atomic.LoadInt32(&stream.idle) == 1 {
+		return *new(T), ErrStreamIdle
+	}
+	return response, err
+}
+
+// canResume reports whether the stream may be requested again after err.
+func (stream *streamReader[T]) canResume(err error) bool {
+	if stream.resume == nil || stream.resumesLeft <= 0 || isClosed(stream.closed()) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, ErrStreamIdle) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr)
+}
+
+// resumeStream replaces the response of the stream with a new one continuing
+// from the text received so far.
+func (stream *streamReader[T]) resumeStream() error {
+	stream.resumesLeft--
+	resp, err := stream.resume(stream.received.String())
+	if err != nil {
+		return err
+	}
+
+	stream.mu.Lock()
+	if isClosed(stream.closed()) {
+		stream.mu.Unlock()
+		resp.Body.Close()
+		return net.ErrClosed
+	}
+	previous := stream.response
+	stream.response = resp
+	stream.mu.Unlock()
+	previous.Body.Close()
+
+	stream.reader = bufio.NewReader(resp.Body)
+	stream.decoder = nil
+	stream.errAccumulator = utils.NewErrorAccumulator()
+	atomic.StoreInt32(&stream.idle, 0)
+	return nil
 }
 
-//nolint:gocognit
 func (stream *streamReader[T]) processLines() (T, error) {
-	var (
-		emptyMessagesCount uint
-		hasErrorPrefix     bool
-	)
+	if stream.decoder == nil {
+		stream.decoder = utils.NewSSEDecoder(stream.reader)
+	}
 
+	var emptyMessagesCount uint
 	for {
-		rawLine, readErr := stream.reader.ReadBytes('\n')
-		if readErr != nil || hasErrorPrefix {
+		event, readErr := stream.decoder.Next()
+		if readErr != nil {
 			respErr := stream.unmarshalError()
 			if respErr != nil {
 				return *new(T), fmt.Errorf("error, %w", respErr.Error)
 			}
 			return *new(T), readErr
 		}
+		stream.event = event.Event
 
-		noSpaceLine := bytes.TrimSpace(rawLine)
-		if bytes.HasPrefix(noSpaceLine, errorPrefix) {
-			hasErrorPrefix = true
-		}
-		if !bytes.HasPrefix(noSpaceLine, headerData) || hasErrorPrefix {
-			if hasErrorPrefix {
-				noSpaceLine = bytes.TrimPrefix(noSpaceLine, headerData)
+		for _, line := range event.Unknown {
+			writeErr := stream.errAccumulator.Write(line)
+			if writeErr != nil {
+				return *new(T), writeErr
 			}
-			writeErr := stream.errAccumulator.Write(noSpaceLine)
+		}
+
+		if bytes.HasPrefix(event.Data, errorPrefix) {
+			writeErr := stream.errAccumulator.Write(event.Data)
 			if writeErr != nil {
 				return *new(T), writeErr
 			}
-			emptyMessagesCount++
-			if emptyMessagesCount > stream.emptyMessagesLimit {
-				return *new(T), ErrTooManyEmptyStreamMessages
+			respErr := stream.unmarshalError()
+			if respErr != nil {
+				return *new(T), fmt.Errorf("error, %w", respErr.Error)
 			}
+			continue
+		}
 
+		if !event.HasData() {
+			// Lines that are not fields count like empty messages, so that a
+			// stream of junk fails fast instead of filling the error accumulator.
+			if event.Empty() || len(event.Unknown) > 0 {
+				emptyMessagesCount += uint(len(event.Unknown))
+				if event.Empty() {
+					emptyMessagesCount++
+				}
+				if emptyMessagesCount > stream.emptyMessagesLimit {
+					return *new(T), ErrTooManyEmptyStreamMessages
+				}
+			}
 			continue
 		}
 
-		noPrefixLine := bytes.TrimPrefix(noSpaceLine, headerData)
-		if string(noPrefixLine) == "[DONE]" {
+		if string(event.Data) == "[DONE]" {
 			stream.isFinished = true
+			if stream.usage != nil && stream.onUsage != nil {
+				stream.onUsage(stream.response, *stream.usage)
+			}
 			return *new(T), io.EOF
 		}
 
 		var response T
-		unmarshalErr := stream.unmarshaler.Unmarshal(noPrefixLine, &response)
+		unmarshalErr := stream.unmarshaler.Unmarshal(event.Data, &response)
 		if unmarshalErr != nil {
 			return *new(T), unmarshalErr
 		}
 
+		stream.observe(response)
 		return response, nil
 	}
 }
 
+// LastEventID returns the id of the last event received on the stream, if the
+// server sends ids.
+func (stream *streamReader[T]) LastEventID() string {
+	if stream.decoder == nil {
+		return ""
+	}
+	return stream.decoder.LastEventID()
+}
+
+// LastEvent returns the name of the last event received on the stream, empty
+// for the default "message" event.
+func (stream *streamReader[T]) LastEvent() string {
+	return stream.event
+}
+
+// observe retains the usage and finish reason reported by a chunk.
+func (stream *streamReader[T]) observe(response T) {
+	switch chunk := any(response).(type) {
+	case ChatCompletionStreamResponse:
+		if chunk.Usage != nil {
+			usage := *chunk.Usage
+			stream.usage = &usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != "" {
+				stream.finishReason = choice.FinishReason
+			}
+		}
+		if stream.resume != nil && len(chunk.Choices) == 1 && chunk.Choices[0].Index == 0 {
+			stream.received.WriteString(chunk.Choices[0].Delta.Content)
+		}
+	case CompletionResponse:
+		if chunk.Usage != (Usage{}) {
+			usage := chunk.Usage
+			stream.usage = &usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != "" {
+				stream.finishReason = FinishReason(choice.FinishReason)
+			}
+		}
+	}
+}
+
+// Usage returns the token usage reported by the stream, usually with its last
+// chunk, or nil if none was received yet.
+func (stream *streamReader[T]) Usage() *Usage {
+	if stream.usage == nil {
+		return nil
+	}
+	usage := *stream.usage
+	return &usage
+}
+
+// FinishReason returns the last finish reason received on the stream.
+func (stream *streamReader[T]) FinishReason() FinishReason {
+	return stream.finishReason
+}
+
 func (stream *streamReader[T]) unmarshalError() (errResp *ErrorResponse) {
 	errBytes := stream.errAccumulator.Bytes()
 	if len(errBytes) == 0 {
@@ -103,11 +272,27 @@ func (stream *streamReader[T]) unmarshalError() (errResp *ErrorResponse) {
 	err := stream.unmarshaler.Unmarshal(errBytes, &errResp)
 	if err != nil {
 		errResp = nil
+		return
 	}
+	errResp.setRequestID()
 
 	return
 }
 
+// closed returns a channel that is closed once Close has been called.
+func (stream *streamReader[T]) closed() <-chan struct{} {
+	stream.doneOnce.Do(func() {
+		stream.done = make(chan struct{})
+	})
+	return stream.done
+}
+
 func (stream *streamReader[T]) Close() error {
+	stream.closed()
+	stream.closeOnce.Do(func() {
+		close(stream.done)
+	})
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
 	return stream.response.Body.Close()
 }
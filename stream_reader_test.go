@@ -52,9 +52,34 @@ func TestStreamReaderReturnsErrTooManyEmptyStreamMessages(t *testing.T) {
 	checks.ErrorIs(t, err, ErrTooManyEmptyStreamMessages, "Did not return error when recv failed", err.Error())
 }
 
+func TestStreamReaderCountsUnknownLinesAsEmptyMessages(t *testing.T) {
+	stream := &streamReader[ChatCompletionStreamResponse]{
+		emptyMessagesLimit: 3,
+		reader:             bufio.NewReader(bytes.NewReader([]byte("garbage\n\ngarbage\n\njunk\njunk\n\ndata: [DONE]\n\n"))),
+		errAccumulator:     utils.NewErrorAccumulator(),
+		unmarshaler:        &utils.JSONUnmarshaler{},
+	}
+	_, err := stream.Recv()
+	checks.ErrorIs(t, err, ErrTooManyEmptyStreamMessages, "Did not return error for a stream of junk lines")
+}
+
+func TestStreamReaderSkipsEmptyData(t *testing.T) {
+	stream := &streamReader[ChatCompletionStreamResponse]{
+		emptyMessagesLimit: 3,
+		reader:             bufio.NewReader(bytes.NewReader([]byte("data:\n\ndata: {\"id\":\"1\"}\n\n"))),
+		errAccumulator:     utils.NewErrorAccumulator(),
+		unmarshaler:        &utils.JSONUnmarshaler{},
+	}
+	response, err := stream.Recv()
+	checks.NoError(t, err, "empty data should be skipped")
+	if response.ID != "1" {
+		t.Errorf("unexpected response: %+v", response)
+	}
+}
+
 func TestStreamReaderReturnsErrTestErrorAccumulatorWriteFailed(t *testing.T) {
 	stream := &streamReader[ChatCompletionStreamResponse]{
-		reader: bufio.NewReader(bytes.NewReader([]byte("\n"))),
+		reader: bufio.NewReader(bytes.NewReader([]byte("{\n"))),
 		errAccumulator: &utils.DefaultErrorAccumulator{
 			Buffer: &test.FailingErrorBuffer{},
 		},
@@ -0,0 +1,214 @@
+package zhipuai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bbang94/go-zhipuai/jsonschema"
+)
+
+const defaultToolRunnerMaxIterations = 10
+
+var (
+	ErrToolAlreadyRegistered   = errors.New("a tool with this name is already registered")
+	ErrToolNotRegistered       = errors.New("the model called a tool that is not registered")
+	ErrToolRunnerMaxIterations = errors.New("the model still calls tools after the maximum number of iterations")
+)
+
+// ToolFunc executes a tool call. It receives the JSON arguments generated by
+// the model and returns the content of the tool message sent back to it.
+type ToolFunc func(ctx context.Context, arguments string) (string, error)
+
+// ToolRunner runs the tool-calling loop of a chat completion: it sends the
+// request with the registered functions as tools, executes the tool calls
+// requested by the model and replies with their results until the model answers.
+type ToolRunner struct {
+	client    *Client
+	functions map[string]ToolFunc
+	tools     []Tool
+
+	// MaxIterations is the maximum number of chat completions requested by a run.
+	// Defaults to 10.
+	MaxIterations int
+	// StreamHandler receives the chunks of every completion of a streaming run.
+	StreamHandler ChatCompletionStreamHandler
+}
+
+// ToolRunResult is the final response of a tool-calling run.
+type ToolRunResult struct {
+	ChatCompletionResponse
+	// Messages is the whole dialogue: the request messages followed by the
+	// assistant and tool messages of the run, the final answer included.
+	Messages []ChatCompletionMessage
+	// Iterations is the number of chat completions requested.
+	Iterations int
+}
+
+func NewToolRunner(client *Client) *ToolRunner {
+	return &ToolRunner{
+		client:        client,
+		functions:     make(map[string]ToolFunc),
+		MaxIterations: defaultToolRunnerMaxIterations,
+	}
+}
+
+// Register adds a function that the model can call, described by definition.
+func (r *ToolRunner) Register(definition FunctionDefinition, fn ToolFunc) error {
+	if _, ok := r.functions[definition.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrToolAlreadyRegistered, definition.Name)
+	}
+	r.functions[definition.Name] = fn
+	r.tools = append(r.tools, Tool{Type: ToolTypeFunction, Function: &definition})
+	return nil
+}
+
+// Tools returns the registered functions as request tools.
+func (r *ToolRunner) Tools() []Tool {
+	return append([]Tool(nil), r.tools...)
+}
+
+// RunWithTools sends the request with the registered tools appended to its
+// own, executes the tool calls of the first choice, concurrently when there
+// are several, and sends their results back until the model stops calling
+// tools. The request is streamed when request.Stream is set, with its chunks
+// dispatched to StreamHandler.
+//
+// Arguments are validated before a call when the function parameters are a
+// jsonschema.Definition. A failed tool call ends the run with its error. The
+// result holds the dialogue so far when the run fails or exceeds MaxIterations.
+func (r *ToolRunner) RunWithTools(
+	ctx context.Context,
+	request ChatCompletionRequest,
+) (result ToolRunResult, err error) {
+	request.Tools = append(append([]Tool(nil), request.Tools...), r.tools...)
+	result.Messages = append([]ChatCompletionMessage(nil), request.Messages...)
+
+	for result.Iterations < r.maxIterations() {
+		request.Messages = result.Messages
+		result.Iterations++
+		result.ChatCompletionResponse, err = r.createChatCompletion(ctx, request)
+		if err != nil || len(result.Choices) == 0 {
+			return
+		}
+
+		message := result.Choices[0].Message
+		result.Messages = append(result.Messages, message)
+		if len(message.ToolCalls) == 0 {
+			return
+		}
+
+		var replies []ChatCompletionMessage
+		replies, err = r.callTools(ctx, message.ToolCalls)
+		if err != nil {
+			return
+		}
+		result.Messages = append(result.Messages, replies...)
+	}
+	err = ErrToolRunnerMaxIterations
+	return
+}
+
+func (r *ToolRunner) definition(name string) *FunctionDefinition {
+	for _, tool := range r.tools {
+		if tool.Function.Name == name {
+			return tool.Function
+		}
+	}
+	return nil
+}
+
+func (r *ToolRunner) maxIterations() int {
+	if r.MaxIterations <= 0 {
+		return defaultToolRunnerMaxIterations
+	}
+	return r.MaxIterations
+}
+
+func (r *ToolRunner) createChatCompletion(
+	ctx context.Context,
+	request ChatCompletionRequest,
+) (response ChatCompletionResponse, err error) {
+	if !request.Stream {
+		return r.client.CreateChatCompletion(ctx, request)
+	}
+
+	stream, err := r.client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return
+	}
+	defer stream.Close()
+
+	handler := r.StreamHandler
+	handler.OnFinish = func(finished ChatCompletionResponse) {
+		response = finished
+		if r.StreamHandler.OnFinish != nil {
+			r.StreamHandler.OnFinish(finished)
+		}
+	}
+	err = stream.Run(ctx, handler)
+	return
+}
+
+// validateArguments checks the arguments of a tool call when the parameters of
+// its function are described by a jsonschema.Definition.
+func validateArguments(definition *FunctionDefinition, arguments string) error {
+	var schema jsonschema.Definition
+	switch params := definition.Parameters.(type) {
+	case jsonschema.Definition:
+		schema = params
+	case *jsonschema.Definition:
+		if params == nil {
+			return nil
+		}
+		schema = *params
+	default:
+		return nil
+	}
+	return schema.Validate([]byte(arguments))
+}
+
+// callTools executes the tool calls concurrently and returns their replies in
+// the order of the calls.
+func (r *ToolRunner) callTools(ctx context.Context, toolCalls []ToolCall) ([]ChatCompletionMessage, error) {
+	replies := make([]ChatCompletionMessage, len(toolCalls))
+	errs := make([]error, len(toolCalls))
+
+	for _, toolCall := range toolCalls {
+		if _, ok := r.functions[toolCall.Function.Name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrToolNotRegistered, toolCall.Function.Name)
+		}
+		if err := validateArguments(r.definition(toolCall.Function.Name), toolCall.Function.Arguments); err != nil {
+			return nil, fmt.Errorf("tool %s: %w", toolCall.Function.Name, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, toolCall := range toolCalls {
+		fn := r.functions[toolCall.Function.Name]
+		wg.Add(1)
+		go func(i int, toolCall ToolCall) {
+			defer wg.Done()
+			content, err := fn(ctx, toolCall.Function.Arguments)
+			if err != nil {
+				errs[i] = fmt.Errorf("tool %s: %w", toolCall.Function.Name, err)
+				return
+			}
+			replies[i] = ChatCompletionMessage{
+				Role:       ChatMessageRoleTool,
+				Content:    content,
+				Name:       toolCall.Function.Name,
+				ToolCallID: toolCall.ID,
+			}
+		}(i, toolCall)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return replies, nil
+}
@@ -0,0 +1,178 @@
+package zhipuai_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bbang94/go-zhipuai"
+	"github.com/bbang94/go-zhipuai/internal/test/checks"
+	"github.com/bbang94/go-zhipuai/jsonschema"
+)
+
+func newTestToolRunner(t *testing.T, client *zhipuai.Client) *zhipuai.ToolRunner {
+	t.Helper()
+	runner := zhipuai.NewToolRunner(client)
+	err := runner.Register(zhipuai.FunctionDefinition{Name: "get_weather"}, func(_ context.Context, args string) (string, error) {
+		var params struct {
+			City string `json:"city"`
+		}
+		if err := json.Unmarshal([]byte(args), &params); err != nil {
+			return "", err
+		}
+		return "Sunny in " + params.City, nil
+	})
+	checks.NoError(t, err, "Register error")
+	err = runner.Register(zhipuai.FunctionDefinition{Name: "get_weather"}, nil)
+	checks.ErrorIs(t, err, zhipuai.ErrToolAlreadyRegistered, "Register should reject duplicate names")
+	return runner
+}
+
+func TestToolRunnerRunWithTools(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	requests := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		req, err := getChatCompletionBody(r)
+		checks.NoError(t, err, "could not read request")
+		if len(req.Tools) != 1 || req.Tools[0].Function.Name != "get_weather" {
+			http.Error(w, "expected the registered tool", http.StatusBadRequest)
+			return
+		}
+
+		message := zhipuai.ChatCompletionMessage{Role: zhipuai.ChatMessageRoleAssistant}
+		if requests == 1 {
+			message.ToolCalls = []zhipuai.ToolCall{
+				{ID: "call_1", Type: zhipuai.ToolTypeFunction,
+					Function: zhipuai.FunctionCall{Name: "get_weather", Arguments: `{"city":"Beijing"}`}},
+				{ID: "call_2", Type: zhipuai.ToolTypeFunction,
+					Function: zhipuai.FunctionCall{Name: "get_weather", Arguments: `{"city":"Shanghai"}`}},
+			}
+		} else {
+			var replies []string
+			for _, m := range req.Messages[2:] {
+				replies = append(replies, m.ToolCallID+": "+m.Content)
+			}
+			message.Content = fmt.Sprint(replies)
+		}
+		resBytes, _ := json.Marshal(zhipuai.ChatCompletionResponse{
+			Choices: []zhipuai.ChatCompletionChoice{{Message: message}},
+		})
+		fmt.Fprintln(w, string(resBytes))
+	})
+
+	result, err := newTestToolRunner(t, client).RunWithTools(context.Background(), testChatCompletionRequest)
+	checks.NoError(t, err, "RunWithTools error")
+	if result.Iterations != 2 || len(result.Messages) != 5 {
+		t.Fatalf("unexpected run: %d iterations, messages %+v", result.Iterations, result.Messages)
+	}
+	if reply := result.Messages[3]; reply.Role != zhipuai.ChatMessageRoleTool || reply.Name != "get_weather" {
+		t.Errorf("expected a tool message, got %+v", reply)
+	}
+	expected := "[call_1: Sunny in Beijing call_2: Sunny in Shanghai]"
+	if result.Choices[0].Message.Content != expected {
+		t.Errorf("expected %q, got %q", expected, result.Choices[0].Message.Content)
+	}
+}
+
+func TestToolRunnerStream(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	requests := 0
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/event-stream")
+		data := testToolCallStream
+		if requests > 1 {
+			data = `data: {"id":"2","choices":[{"index":0,"delta":{"content":"Sunny"},"finish_reason":"stop"}]}` +
+				"\n\ndata: [DONE]\n\n"
+		}
+		_, err := w.Write([]byte(data))
+		checks.NoError(t, err, "Write error")
+	})
+
+	runner := newTestToolRunner(t, client)
+	var text string
+	runner.StreamHandler.OnDelta = func(_ int, delta zhipuai.ChatCompletionStreamChoiceDelta) {
+		text += delta.Content
+	}
+	request := testChatCompletionRequest
+	request.Stream = true
+	result, err := runner.RunWithTools(context.Background(), request)
+	checks.NoError(t, err, "RunWithTools error")
+	if text != "Let me checkSunny" || result.Choices[0].Message.Content != "Sunny" {
+		t.Errorf("unexpected streamed text %q and response %+v", text, result.ChatCompletionResponse)
+	}
+	if reply := result.Messages[2]; reply.ToolCallID != "call_1" || reply.Content != "Sunny in Beijing" {
+		t.Errorf("unexpected tool message: %+v", reply)
+	}
+}
+
+func TestToolRunnerErrors(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	toolName := "get_weather"
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		resBytes, _ := json.Marshal(zhipuai.ChatCompletionResponse{
+			Choices: []zhipuai.ChatCompletionChoice{{Message: zhipuai.ChatCompletionMessage{
+				Role: zhipuai.ChatMessageRoleAssistant,
+				ToolCalls: []zhipuai.ToolCall{{ID: "call_1", Type: zhipuai.ToolTypeFunction,
+					Function: zhipuai.FunctionCall{Name: toolName, Arguments: `{"city":"Beijing"}`}}},
+			}}},
+		})
+		fmt.Fprintln(w, string(resBytes))
+	})
+
+	runner := newTestToolRunner(t, client)
+	runner.MaxIterations = 3
+	result, err := runner.RunWithTools(context.Background(), testChatCompletionRequest)
+	checks.ErrorIs(t, err, zhipuai.ErrToolRunnerMaxIterations, "RunWithTools should stop after MaxIterations")
+	if result.Iterations != 3 {
+		t.Errorf("expected 3 iterations, got %d", result.Iterations)
+	}
+
+	toolName = "get_time"
+	_, err = runner.RunWithTools(context.Background(), testChatCompletionRequest)
+	checks.ErrorIs(t, err, zhipuai.ErrToolNotRegistered, "RunWithTools should reject unknown tools")
+
+	errBroken := errors.New("broken")
+	checks.NoError(t, runner.Register(zhipuai.FunctionDefinition{Name: "get_time"},
+		func(context.Context, string) (string, error) { return "", errBroken }), "Register error")
+	_, err = runner.RunWithTools(context.Background(), testChatCompletionRequest)
+	checks.ErrorIs(t, err, errBroken, "RunWithTools should return tool errors")
+}
+
+func TestToolRunnerValidatesArguments(t *testing.T) {
+	client, server, teardown := setupzhipuaiTestServer()
+	defer teardown()
+	server.RegisterHandler("/v1/chat/completions", func(w http.ResponseWriter, _ *http.Request) {
+		resBytes, _ := json.Marshal(zhipuai.ChatCompletionResponse{
+			Choices: []zhipuai.ChatCompletionChoice{{Message: zhipuai.ChatCompletionMessage{
+				Role: zhipuai.ChatMessageRoleAssistant,
+				ToolCalls: []zhipuai.ToolCall{{ID: "call_1", Type: zhipuai.ToolTypeFunction,
+					Function: zhipuai.FunctionCall{Name: "get_weather", Arguments: `{"city":42}`}}},
+			}}},
+		})
+		fmt.Fprintln(w, string(resBytes))
+	})
+
+	definition, err := zhipuai.FunctionDefinitionFor[struct {
+		City string `json:"city"`
+	}]("get_weather", "")
+	checks.NoError(t, err, "FunctionDefinitionFor error")
+	runner := zhipuai.NewToolRunner(client)
+	checks.NoError(t, runner.Register(definition, func(context.Context, string) (string, error) {
+		t.Error("the tool should not be called with invalid arguments")
+		return "", nil
+	}), "Register error")
+
+	_, err = runner.RunWithTools(context.Background(), testChatCompletionRequest)
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Path != "$.city" {
+		t.Errorf("expected a validation error, got %v", err)
+	}
+}